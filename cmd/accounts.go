@@ -1,13 +1,24 @@
 package cmd
 
 import (
+	"github.com/Optum/dce-cli/pkg/service"
 	"github.com/spf13/cobra"
 )
 
 var accountID string
 var adminRoleARN string
 
+var accountsListOpts = &service.AccountListOptions{}
+
 func init() {
+	accountsListCmd.Flags().StringVarP(&accountsListOpts.Status, "status", "s", "", "Account status. One of: Ready, NotReady, Leased, Orphaned")
+	accountsListCmd.Flags().StringVar(&accountsListOpts.AdminRoleArn, "admin-role-arn", "", "Only list accounts with this admin role ARN")
+	accountsListCmd.Flags().StringVar(&accountsListOpts.PrincipalRoleArn, "principal-role-arn", "", "Only list accounts with this principal role ARN")
+	accountsListCmd.Flags().StringVar(&accountsListOpts.PrincipalPolicyHash, "principal-policy-hash", "", "Only list accounts with this principal policy hash")
+	accountsListCmd.Flags().Int64VarP(&accountsListOpts.Limit, "limit", "l", 0, "Max number of accounts to return at once. Will include url to next page if there is one.")
+	accountsListCmd.Flags().StringVar(&accountsListOpts.NextID, "next-id", "", "Account ID with which to begin the scan operation. This is used to traverse through paginated results.")
+	accountsListCmd.Flags().BoolVar(&accountsListOpts.All, "all", false, "Follow pagination links, and return all matching accounts.")
+	accountsListCmd.Flags().Int64Var(&accountsListOpts.MaxItems, "max-items", 0, "Max number of accounts to return in total. Follows pagination links until reached.")
 	accountsCmd.AddCommand(accountsListCmd)
 
 	accountsAddCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "The ID of the existing account to add to the DCE accounts pool (WARNING: Account will be nuked.)")
@@ -34,8 +45,9 @@ var accountsDescribeCmd = &cobra.Command{
 	Use:   "describe [Accound ID]",
 	Short: "describe an account",
 	Args:  cobra.ExactValidArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.GetAccount(args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.GetAccount(commandCtx, args[0])
 	},
 }
 
@@ -43,8 +55,9 @@ var accountsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "list accounts",
 	Args:  cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.ListAccounts()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.ListAccounts(commandCtx, accountsListOpts)
 	},
 }
 
@@ -52,8 +65,9 @@ var accountsAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add an account to the accounts pool",
 	Args:  cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.AddAccount(accountID, adminRoleARN)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.AddAccount(commandCtx, accountID, adminRoleARN)
 	},
 }
 
@@ -61,7 +75,8 @@ var accountsRemoveCmd = &cobra.Command{
 	Use:   "remove [Account ID]",
 	Short: "Remove an account from the accounts pool.",
 	Args:  cobra.ExactValidArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.RemoveAccount(args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.RemoveAccount(commandCtx, args[0])
 	},
 }
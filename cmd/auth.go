@@ -1,18 +1,49 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/Optum/dce-cli/pkg/service"
 	"github.com/spf13/cobra"
 )
 
+var authMode string
+
 func init() {
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
 	RootCmd.AddCommand(authCmd)
-	authCmd.Flags()
+	authCmd.Flags().StringVarP(&authMode, "mode", "m", "", fmt.Sprintf(
+		"How to receive the API token from the auth page: %s. "+
+			"\"loopback\" receives the token automatically, via a localhost server. "+
+			"Defaults to the api.authMode config, or \"paste\"",
+		strings.Join(service.AuthModes, ", ")))
 }
 
 var authCmd = &cobra.Command{
 	Use:   "auth",
 	Short: "Login to dce",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return Service.Authenticate()
+		cmd.SilenceUsage = true
+		return Service.Authenticate(commandCtx, &service.AuthOptions{Mode: authMode})
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the credentials used to access dce, and when they expire",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.AuthStatus(commandCtx)
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Logout of dce, and remove AWS CLI profiles written by `dce leases login`",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.Logout()
 	},
 }
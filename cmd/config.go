@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configValidateCmd)
+	RootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and edit the DCE config file",
+	Long: `View and edit the DCE config file.
+
+Settings are identified by their dot separated YAML keys, eg. "api.host", "deploy.region",
+"timeouts.commands.leases list", or "contexts.prod.region" for the settings of a named context.`,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the config file, with API tokens redacted",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.ViewConfig()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:     "get [Key]",
+	Short:   "Print the value of a setting",
+	Example: "dce config get api.host",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.GetConfig(args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [Key] [Value]",
+	Short: "Set the value of a setting. Lists are comma separated",
+	Example: `dce config set region us-east-1
+dce config set contexts.prod.api.host abcdefghij.execute-api.us-east-1.amazonaws.com
+dce config set aws.roleArns arn:aws:iam::123456789012:role/DCEAdmin`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.SetConfig(args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:     "unset [Key]",
+	Short:   "Remove a setting",
+	Example: "dce config unset api.retry.maxAttempts",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.UnsetConfig(args[0])
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for unknown keys and invalid values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.ValidateConfig()
+	},
+}
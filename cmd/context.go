@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"github.com/Optum/dce-cli/configs"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextAddCmd)
+
+	contextCmd.AddCommand(contextRemoveCmd)
+	RootCmd.AddCommand(contextCmd)
+}
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage named contexts, for working with multiple DCE deployments",
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contexts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.ListContexts()
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:     "use [Context Name]",
+	Short:   "Set the context used by default. Use \"default\" for the top-level settings in the config file",
+	Example: "dce context use prod",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.UseContext(args[0])
+	},
+}
+
+var contextAddCmd = &cobra.Command{
+	Use:     "add [Context Name]",
+	Short:   "Add a context, with the --api-host, --api-base-path and --region flags",
+	Example: "dce context add prod --api-host abcdefghij.execute-api.us-east-1.amazonaws.com --api-base-path /api --region us-east-1",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		settings := &configs.Context{}
+		if apiHost != "" {
+			settings.API.Host = &apiHost
+		}
+		if apiBasePath != "" {
+			settings.API.BasePath = &apiBasePath
+		}
+		if region != "" {
+			settings.Region = &region
+		}
+		return Service.AddContext(args[0], settings)
+	},
+}
+
+var contextRemoveCmd = &cobra.Command{
+	Use:   "remove [Context Name]",
+	Short: "Remove a context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.RemoveContext(args[0])
+	},
+}
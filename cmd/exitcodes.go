@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+
+	svc "github.com/Optum/dce-cli/pkg/service"
+)
+
+// Process exit codes, used by scripts to distinguish between failures.
+// These are documented in the README, so existing values must not change.
+const (
+	ExitCodeSuccess      = 0
+	ExitCodeError        = 1
+	ExitCodeValidation   = 2
+	ExitCodeUnauthorized = 3
+	ExitCodeNotFound     = 4
+	ExitCodeConflict     = 5
+	ExitCodeNetwork      = 6
+	ExitCodeTimeout      = 7
+	ExitCodeServer       = 8
+	// ExitCodeLeaseOverBudget and ExitCodeLeaseEnded are returned
+	// by `dce leases watch` when the watched lease ends
+	ExitCodeLeaseOverBudget = 9
+	ExitCodeLeaseEnded      = 10
+	// ExitCodeInterrupted follows the shell convention of 128 + SIGINT
+	ExitCodeInterrupted = 130
+)
+
+// exitCode maps an error returned by a command
+// to the process exit code. Commands run by `dce leases exec`
+// exit with their own exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var cmdErr *svc.CommandExitError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code
+	}
+
+	switch svc.KindOf(err) {
+	case svc.ValidationError:
+		return ExitCodeValidation
+	case svc.UnauthorizedError:
+		return ExitCodeUnauthorized
+	case svc.NotFoundError:
+		return ExitCodeNotFound
+	case svc.ConflictError:
+		return ExitCodeConflict
+	case svc.NetworkError:
+		return ExitCodeNetwork
+	case svc.TimeoutError:
+		return ExitCodeTimeout
+	case svc.ServerError:
+		return ExitCodeServer
+	case svc.LeaseOverBudgetError:
+		return ExitCodeLeaseOverBudget
+	case svc.LeaseEndedError:
+		return ExitCodeLeaseEnded
+	case svc.CanceledError:
+		return ExitCodeInterrupted
+	default:
+		return ExitCodeError
+	}
+}
@@ -14,7 +14,8 @@ func init() {
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: fmt.Sprintf("First time DCE cli setup. Creates config file at \"%s\" (by default) or at the location specified by \"--config\"", constants.ConfigFileDefaultLocationUnexpanded),
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.InitializeDCE()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.InitializeDCE()
 	},
 }
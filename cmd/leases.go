@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/Optum/dce-cli/pkg/service"
 	"github.com/spf13/cobra"
 )
@@ -12,17 +16,29 @@ var acctID string
 var loginOpenBrowser bool
 var loginPrintCreds bool
 var loginProfile string
+var credentialProcessProfile string
 
 var principalID string
 var budgetAmount float64
 var budgetCurrency string
 var email []string
 var expiresOn string
+var createWait bool
+var createWaitTimeout time.Duration
+var createLogin bool
+var leaseSpecFile string
+
+// leaseCreateRequiredFlags are required by `dce leases create`, unless --file is used
+var leaseCreateRequiredFlags = []string{"principal-id", "budget-amount", "budget-currency", "email"}
 
 var pagLimit int64
 var nextAcctID string
 var nextPrincipalID string
 var leaseStatus string
+var listAll bool
+var listMaxItems int64
+
+var watchInterval time.Duration
 
 func init() {
 	leasesCmd.AddCommand(leasesDescribeCmd)
@@ -34,6 +50,8 @@ func init() {
 	leasesListCmd.Flags().StringVarP(&nextPrincipalID, "next-principal-id", "", "", "Principal ID with which to begin the scan operation. This is used to traverse through paginated results.")
 	leasesListCmd.Flags().StringVarP(&principalID, "principal-id", "p", "", "Principle ID of a user")
 	leasesListCmd.Flags().StringVarP(&leaseStatus, "status", "s", "", "Lease status")
+	leasesListCmd.Flags().BoolVar(&listAll, "all", false, "Follow pagination links, and return all matching leases.")
+	leasesListCmd.Flags().Int64Var(&listMaxItems, "max-items", 0, "Max number of leases to return in total. Follows pagination links until reached.")
 	leasesCmd.AddCommand(leasesListCmd)
 
 	leasesCreateCmd.Flags().StringVarP(&principalID, "principal-id", "p", "", "Principle ID for the user of the leased account")
@@ -41,18 +59,13 @@ func init() {
 	leasesCreateCmd.Flags().StringVarP(&budgetCurrency, "budget-currency", "c", "USD", "The leased accounts budget currency")
 	leasesCreateCmd.Flags().StringVarP(&expiresOn, "expires-on", "E", "7d", "The leased accounts expiry date as a long (UNIX epoch) or string (eg., '7d', '8h'")
 	leasesCreateCmd.Flags().StringArrayVarP(&email, "email", "e", nil, "The email address that budget notifications will be sent to")
-	if err := leasesCreateCmd.MarkFlagRequired("principal-id"); err != nil {
-		log.Fatalln(err)
-	}
-	if err := leasesCreateCmd.MarkFlagRequired("budget-amount"); err != nil {
-		log.Fatalln(err)
-	}
-	if err := leasesCreateCmd.MarkFlagRequired("budget-currency"); err != nil {
-		log.Fatalln(err)
-	}
-	if err := leasesCreateCmd.MarkFlagRequired("email"); err != nil {
-		log.Fatalln(err)
-	}
+	leasesCreateCmd.Flags().BoolVar(&createWait, "wait", false, "Wait until the lease is active, and its account is ready to use")
+	leasesCreateCmd.Flags().DurationVar(&createWaitTimeout, "wait-timeout", service.DefaultLeaseWaitTimeout, "How long to wait for the lease to become active (eg. \"5m\")")
+	leasesCreateCmd.Flags().BoolVar(&createLogin, "login", false, "Login to the leased account once the lease is active. Implies --wait")
+	leasesCreateCmd.Flags().BoolVar(&loginOpenBrowser, "open-browser", false, "With --login, opens web browser to AWS console instead of adding AWS CLI credentials")
+	leasesCreateCmd.Flags().BoolVar(&loginPrintCreds, "print-creds", false, "With --login, prints credentials rather than adding them to .aws/credentials file")
+	leasesCreateCmd.Flags().StringVar(&loginProfile, "profile", "default", "With --login, adds aws cli credentials to a specific profile")
+	leasesCreateCmd.Flags().StringVarP(&leaseSpecFile, "file", "f", "", "Create the leases described by a YAML or JSON lease spec file, or - for stdin. Other flags override the file")
 	leasesCmd.AddCommand(leasesCreateCmd)
 
 	leasesEndCmd.Flags().StringVarP(&principalID, "principal-id", "p", "", "Principle ID for the user of the leased account")
@@ -64,6 +77,17 @@ func init() {
 	leasesLoginCmd.Flags().StringVarP(&loginProfile, "profile", "p", "default", "Add aws cli credentials to a specific profile")
 	leasesCmd.AddCommand(leasesLoginCmd)
 
+	leasesWatchCmd.Flags().DurationVarP(&watchInterval, "interval", "i", service.DefaultWatchInterval, "How often to check the lease (eg. \"1m\")")
+	leasesCmd.AddCommand(leasesWatchCmd)
+
+	leasesCmd.AddCommand(leasesExecCmd)
+	leasesCmd.AddCommand(leasesShellCmd)
+
+	leasesCmd.AddCommand(leasesCredentialProcessCmd)
+
+	leasesConfigureProfileCmd.Flags().StringVarP(&credentialProcessProfile, "profile", "p", "dce", "Name of the AWS CLI profile to configure")
+	leasesCmd.AddCommand(leasesConfigureProfileCmd)
+
 	RootCmd.AddCommand(leasesCmd)
 }
 
@@ -76,8 +100,9 @@ var leasesDescribeCmd = &cobra.Command{
 	Use:   "describe [Lease ID]",
 	Short: "describe a lease",
 	Args:  cobra.ExactValidArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.GetLease(args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.GetLease(commandCtx, args[0])
 	},
 }
 
@@ -85,17 +110,75 @@ var leasesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List leases using various query filters.",
 	Args:  cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.ListLeases(acctID, principalID, nextAcctID, nextPrincipalID, leaseStatus, pagLimit)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.ListLeases(commandCtx, &service.LeaseListOptions{
+			AccountID:       acctID,
+			PrincipalID:     principalID,
+			NextAccountID:   nextAcctID,
+			NextPrincipalID: nextPrincipalID,
+			Status:          leaseStatus,
+			Limit:           pagLimit,
+			All:             listAll,
+			MaxItems:        listMaxItems,
+		})
 	},
 }
 
 var leasesCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a lease.",
-	Args:  cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.CreateLease(principalID, budgetAmount, budgetCurrency, email, expiresOn)
+	Example: "dce leases create -p <principalID> -b 100 -c USD -e <email>\n" +
+		"dce leases create -p <principalID> -b 100 -c USD -e <email> --login --profile my-lease\n" +
+		"dce leases create -f lease.yaml",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if leaseSpecFile == "" {
+			missing := []string{}
+			for _, flag := range leaseCreateRequiredFlags {
+				if !cmd.Flags().Changed(flag) {
+					missing = append(missing, flag)
+				}
+			}
+			if len(missing) > 0 {
+				return service.NewValidationError(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
+			}
+		}
+
+		cmd.SilenceUsage = true
+		opts := &service.LeaseCreateOptions{
+			Wait:        createWait,
+			WaitTimeout: createWaitTimeout,
+		}
+		if createLogin {
+			opts.Login = &service.LeaseLoginOptions{
+				CliProfile:  loginProfile,
+				OpenBrowser: loginOpenBrowser,
+				PrintCreds:  loginPrintCreds,
+			}
+		}
+		if leaseSpecFile == "" {
+			return Service.CreateLease(commandCtx, principalID, budgetAmount, budgetCurrency, email, expiresOn, opts)
+		}
+
+		// Flags override the lease spec file
+		overrides := &service.LeaseSpec{}
+		if cmd.Flags().Changed("principal-id") {
+			overrides.PrincipalID = principalID
+		}
+		if cmd.Flags().Changed("budget-amount") {
+			overrides.BudgetAmount = budgetAmount
+		}
+		if cmd.Flags().Changed("budget-currency") {
+			overrides.BudgetCurrency = budgetCurrency
+		}
+		if cmd.Flags().Changed("email") {
+			overrides.BudgetNotificationEmails = email
+		}
+		if cmd.Flags().Changed("expires-on") {
+			overrides.ExpiresOn = expiresOn
+		}
+		return Service.CreateLeasesFromFile(commandCtx, leaseSpecFile, overrides, opts)
 	},
 }
 
@@ -103,7 +186,8 @@ var leasesEndCmd = &cobra.Command{
 	Use:     "end [Lease ID]",
 	Short:   "Cause a lease to immediately expire",
 	Example: "dce leases end <leaseID>\ndce leases end --principal-id <principalID> --account-id <accountID>",
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		leaseID := ""
 		if len(args) == 1 {
@@ -111,10 +195,11 @@ var leasesEndCmd = &cobra.Command{
 		}
 
 		if !((leaseID != "") || (accountID != "" && principalID != "")) {
-			log.Println("Please provide either a lease ID argument or --principal-id and --account-id flags")
-		} else {
-			Service.EndLease(leaseID, accountID, principalID)
+			return service.NewValidationError("Please provide either a lease ID argument or --principal-id and --account-id flags")
 		}
+
+		cmd.SilenceUsage = true
+		return Service.EndLease(commandCtx, leaseID, accountID, principalID)
 	},
 }
 
@@ -124,7 +209,8 @@ var leasesLoginCmd = &cobra.Command{
 		"If no Lease ID is provided, uses the active lease for the requesting user. \n" +
 		"Sets AWS CLI credentials if used with no flags",
 	Args: cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
 		opts := &service.LeaseLoginOptions{
 			CliProfile:  loginProfile,
 			OpenBrowser: loginOpenBrowser,
@@ -132,10 +218,105 @@ var leasesLoginCmd = &cobra.Command{
 		}
 
 		if len(args) == 0 {
-			Service.Login(opts)
-		} else {
-			Service.LoginByID(args[0], opts)
+			return Service.Login(commandCtx, opts)
 		}
+		return Service.LoginByID(commandCtx, args[0], opts)
+	},
+}
+
+var leasesWatchCmd = &cobra.Command{
+	Use: "watch [Lease ID]",
+	Short: "Watch a lease until it ends, printing its remaining budget, time until it expires, and status changes. \n" +
+		"Exits with code 9 if the lease is over budget, or 10 if it expired or was ended",
+	Example: "dce leases watch <leaseID> --interval 5m",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.WatchLease(commandCtx, args[0], &service.LeaseWatchOptions{
+			Interval: watchInterval,
+		})
+	},
+}
 
+var leasesExecCmd = &cobra.Command{
+	Use: "exec [Lease ID] -- [Command] [Args...]",
+	Short: "Run a command with leased account credentials set in its environment. \n" +
+		"If no Lease ID is provided, uses the active lease for the requesting user. \n" +
+		"Credentials are not written to the AWS CLI credentials file",
+	Example: "dce leases exec <leaseID> -- aws s3 ls\ndce leases exec -- terraform apply",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dash := cmd.ArgsLenAtDash()
+		if dash < 0 || dash == len(args) {
+			return service.NewValidationError("Please provide a command to run after --")
+		}
+		if dash > 1 {
+			return service.NewValidationError("Please provide at most one lease ID before --")
+		}
+
+		cmd.SilenceUsage = true
+		leaseID := ""
+		if dash == 1 {
+			leaseID = args[0]
+		}
+		err := Service.Exec(commandCtx, leaseID, args[dash:])
+		// The command prints its own errors
+		var exitErr *service.CommandExitError
+		cmd.SilenceErrors = errors.As(err, &exitErr)
+		return err
+	},
+}
+
+var leasesShellCmd = &cobra.Command{
+	Use: "shell [Lease ID]",
+	Short: "Start a shell which uses leased account credentials. \n" +
+		"If no Lease ID is provided, uses the active lease for the requesting user. \n" +
+		"Credentials are refreshed before they expire, while the shell is running",
+	Example: "dce leases shell <leaseID>",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		leaseID := ""
+		if len(args) == 1 {
+			leaseID = args[0]
+		}
+		err := Service.Shell(commandCtx, leaseID)
+		// The shell exits with the code of the last command run in it
+		var exitErr *service.CommandExitError
+		cmd.SilenceErrors = errors.As(err, &exitErr)
+		return err
+	},
+}
+
+var leasesCredentialProcessCmd = &cobra.Command{
+	Use: "credential-process [Lease ID]",
+	Short: "Print leased account credentials for use as an AWS CLI `credential_process`. \n" +
+		"If no Lease ID is provided, uses the active lease for the requesting user. \n" +
+		"Credentials are cached until they expire",
+	Example: "[profile dce]\ncredential_process = dce leases credential-process <leaseID>",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		leaseID := ""
+		if len(args) == 1 {
+			leaseID = args[0]
+		}
+		return Service.CredentialProcess(commandCtx, leaseID)
+	},
+}
+
+var leasesConfigureProfileCmd = &cobra.Command{
+	Use: "configure-profile [Lease ID]",
+	Short: "Add a profile to the AWS CLI config file, which fetches leased account credentials " +
+		"using `dce leases credential-process`. \n" +
+		"If no Lease ID is provided, uses the active lease for the requesting user",
+	Example: "dce leases configure-profile <leaseID> --profile my-lease\naws s3 ls --profile my-lease",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		leaseID := ""
+		if len(args) == 1 {
+			leaseID = args[0]
+		}
+		return Service.ConfigureCredentialProcess(leaseID, credentialProcessProfile)
 	},
 }
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/constants"
+)
+
+// ContextEnvVar selects the context, like the --context flag
+const ContextEnvVar = "DCE_CONTEXT"
+
+// configDefaults are used for settings which are not
+// set by the config file, env vars, or flags
+var configDefaults = &configs.Root{
+	Deploy: configs.Deploy{
+		Location:                    stringp(constants.DefaultDCELocation),
+		Version:                     stringp(constants.DefaultDCEVersion),
+		AWSRegion:                   stringp("us-east-1"),
+		BudgetNotificationFromEmail: stringp("no-reply@example.com"),
+	},
+}
+
+// DeployRegionEnvVar sets deploy.region, if DCE_DEPLOY_REGION is not set,
+// as it did before deploy settings had DCE_* env vars
+const DeployRegionEnvVar = "AWS_REGION"
+
+// resolveConfig overrides the settings from the config file,
+// in order of precedence: defaults < config file < DCE_* env vars < flags.
+// Overrides are not saved to the config file.
+func resolveConfig() error {
+	if err := Config.ApplyDefaults(configDefaults); err != nil {
+		return err
+	}
+	if err := Config.ApplyEnv(os.LookupEnv); err != nil {
+		return err
+	}
+	if _, ok := os.LookupEnv(configs.EnvVar("deploy.region")); !ok {
+		if val := os.Getenv(DeployRegionEnvVar); val != "" {
+			if err := Config.Override("deploy.region", val, configs.SourceEnv, DeployRegionEnvVar); err != nil {
+				return err
+			}
+		}
+	}
+
+	configFlags := []struct {
+		key  string
+		flag string
+		val  string
+	}{
+		{"api.host", "api-host", apiHost},
+		{"api.basePath", "api-base-path", apiBasePath},
+		{"api.token", "token", apiToken},
+		{"region", "region", region},
+		{"aws.profile", "aws-profile", awsProfile},
+		{"aws.roleArns", "role-arn", strings.Join(roleARNs, ",")},
+		{"deploy.location", "local", DeployConfig.Location},
+		{"deploy.version", "dce-version", DeployConfig.Version},
+		{"deploy.region", "region", DeployConfig.AWSRegion},
+		{"deploy.namespace", "namespace", DeployConfig.Namespace},
+		{"deploy.budgetNotificationFromEmail", "budget-notification-from-email", DeployConfig.BudgetNotificationFromEmail},
+		{"terraform.initOptions", "tf-init-options", DeployConfig.TFInitOptions},
+		{"terraform.applyOptions", "tf-apply-options", DeployConfig.TFApplyOptions},
+	}
+	for _, f := range configFlags {
+		if f.val == "" {
+			continue
+		}
+		if err := Config.Override(f.key, f.val, configs.SourceFlag, "--"+f.flag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printConfigSources logs where each setting came from, for --show-config-sources
+func printConfigSources() {
+	for _, source := range Config.Sources() {
+		from := source.Source
+		if source.Name != "" {
+			from += " " + source.Name
+		}
+		log.Infof("%s = %s (%s)", source.Key, source.Value, from)
+	}
+}
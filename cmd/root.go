@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
@@ -23,7 +24,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Optum/dce-cli/configs"
 	"github.com/Optum/dce-cli/internal/constants"
@@ -35,11 +40,31 @@ import (
 )
 
 var cfgFile string
+var outputFormat string
+var outputColumns []string
+var outputQuery string
+var outputTemplate string
+var timeout time.Duration
+var awsProfile string
+var roleARNs []string
+var contextName string
+var apiHost string
+var apiBasePath string
+var region string
+var apiToken string
+var showConfigSources bool
 var Config = &configs.Root{}
 var Service *svc.ServiceContainer
 var Util *utl.UtilContainer
 var Observation *observ.ObservationContainer
 
+// rootCtx is cancelled when dce receives SIGINT or SIGTERM
+var rootCtx = context.Background()
+
+// commandCtx is passed to services by commands.
+// It carries the API timeout for the command being run.
+var commandCtx = context.Background()
+
 // Expose logger as global for ease of use
 var log observ.Logger
 var Log observ.Logger
@@ -49,6 +74,11 @@ var Log observ.Logger
 var PostInit func(cmd *cobra.Command, args []string) error
 
 func init() {
+	// Invalid flags are user input errors
+	RootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return svc.NewValidationError("%s", err)
+	})
+
 	// Global Flags
 	// ---------------
 	// --config flag, to specify path to dce.yml config
@@ -58,6 +88,82 @@ func init() {
 		"",
 		"config file (default is \"$HOME/.dce/config.yaml\")",
 	)
+	// --output flag, to specify how command output is rendered
+	RootCmd.PersistentFlags().StringVarP(
+		&outputFormat, "output", "o",
+		observ.FormatJSON,
+		fmt.Sprintf("output format. One of: %s", strings.Join(observ.Formats, ", ")),
+	)
+	// --columns flag, to select the fields shown in table and csv output
+	RootCmd.PersistentFlags().StringSliceVar(
+		&outputColumns, "columns",
+		nil,
+		"comma separated list of fields to show in table or csv output (eg. \"id,accountId,leaseStatus\")",
+	)
+	// --query flag, to filter command output using JSONPath
+	RootCmd.PersistentFlags().StringVar(
+		&outputQuery, "query",
+		"",
+		"JSONPath expression used to filter command output (eg. \"$[*].accountId\")",
+	)
+	// --template flag, to render command output with a Go template
+	RootCmd.PersistentFlags().StringVar(
+		&outputTemplate, "template",
+		"",
+		"Go template used to render command output (eg. \"{{.AccountID}}\"). Overrides --output",
+	)
+	// --timeout flag, to override the timeout for DCE API requests
+	RootCmd.PersistentFlags().DurationVar(
+		&timeout, "timeout",
+		0,
+		"Timeout for each DCE API request (eg. \"30s\"). Overrides the timeouts in the config file",
+	)
+	// --context flag, to select a named context from the config file
+	RootCmd.PersistentFlags().StringVar(
+		&contextName, "context",
+		"",
+		"Name of the context to use, for working with multiple DCE deployments. Defaults to the currentContext config",
+	)
+	// --api-host, --api-base-path, --region and --token flags,
+	// to override the config file, eg. when running without one
+	RootCmd.PersistentFlags().StringVar(
+		&apiHost, "api-host",
+		"",
+		"Host of the DCE API (eg. \"abcdefghij.execute-api.us-east-1.amazonaws.com\"). Overrides the api.host config",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&apiBasePath, "api-base-path",
+		"",
+		"Base path of the DCE API (eg. \"/api\"). Overrides the api.basepath config",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&region, "region",
+		"",
+		"AWS region of the DCE deployment. Overrides the region config",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&apiToken, "token",
+		"",
+		"DCE API token, as provided by the DCE auth page. Overrides the api.token config",
+	)
+	// --show-config-sources flag, for debugging where settings come from
+	RootCmd.PersistentFlags().BoolVar(
+		&showConfigSources, "show-config-sources",
+		false,
+		"Print each setting, and whether it came from a default, the config file, an env var, or a flag",
+	)
+	// --aws-profile flag, to use an AWS CLI profile instead of the API token
+	RootCmd.PersistentFlags().StringVar(
+		&awsProfile, "aws-profile",
+		"",
+		"AWS CLI profile to use for AWS and DCE API requests, instead of the API token saved by `dce auth`",
+	)
+	// --role-arn flag, to assume IAM roles
+	RootCmd.PersistentFlags().StringArrayVar(
+		&roleARNs, "role-arn",
+		nil,
+		"ARN of an IAM role to assume for AWS and DCE API requests. Repeat to chain roles, in order",
+	)
 }
 
 // RootCmd represents the base command when called without any subcommands
@@ -79,6 +185,15 @@ func preRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Config commands must work with invalid timeouts,
+	// so that they may be used to fix them
+	isConfigCommand := cmd == configCmd || cmd.Parent() == configCmd
+	reqTimeout, err := apiTimeout(cmd, Config)
+	if err != nil && !isConfigCommand {
+		return err
+	}
+	commandCtx = svc.WithAPITimeout(rootCtx, reqTimeout)
+
 	if PostInit != nil {
 		err = PostInit(cmd, args)
 		if err != nil {
@@ -86,6 +201,17 @@ func preRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Deploy defaults depend on the config dir, so are applied once Util is ready
+	if cmd == systemDeployCmd {
+		if err := applyDeployDefaults(); err != nil {
+			return err
+		}
+	}
+
+	if showConfigSources {
+		printConfigSources()
+	}
+
 	// Check if the requested command is for a version check
 	// If it is, return here, as no creds are needed
 	if cmd.Name() == versionCmd.Name() {
@@ -94,12 +220,16 @@ func preRun(cmd *cobra.Command, args []string) error {
 
 	// Check if the user has valid creds,
 	// otherwise require authentication
-	hasValidCreds := areCredsValid(Util.AWSSession.Config.Credentials)
-	isAuthCommand := cmd.Name() == authCmd.Name()
+	isAuthCommand := cmd == authCmd || cmd.Parent() == authCmd
 	isInitCommand := cmd.Name() == initCmd.Name()
-	if !hasValidCreds && !isAuthCommand && !isInitCommand {
+	isContextCommand := cmd == contextCmd || cmd.Parent() == contextCmd
+	// credential-process is run by AWS SDKs, which can't answer a login prompt,
+	// so it fails rather than logging in
+	isCredentialProcessCommand := cmd == leasesCredentialProcessCmd
+	if !isAuthCommand && !isInitCommand && !isContextCommand && !isConfigCommand && !isCredentialProcessCommand &&
+		!areCredsValid(Util.AWSSession.Config.Credentials) {
 		log.Print("No valid DCE credentials found")
-		err := Service.Authenticate()
+		err := Service.Authenticate(commandCtx, nil)
 		if err != nil {
 			return err
 		}
@@ -134,14 +264,33 @@ func areCredsValid(creds *credentials.Credentials) bool {
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rootCtx = ctx
+	cancelOnSignal(cancel)
+
 	if err := RootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 	// Print an extra newline when we're done,
 	// so users terminal prompt shows up on a new line
 	fmt.Println("")
 }
 
+// cancelOnSignal cancels the root context on SIGINT or SIGTERM,
+// so that in-flight API requests are aborted.
+// A second signal exits immediately.
+func cancelOnSignal(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		fmt.Fprintln(os.Stderr, "Cancelling... Press Ctrl-C again to exit immediately.")
+		cancel()
+	}()
+}
+
 type FmtOutputFormatter struct {
 }
 
@@ -157,42 +306,109 @@ func onInit(cmd *cobra.Command, args []string) error {
 	log = Observation.Logger
 	Log = log
 
+	// Configure the output format for command results
+	formatter, err := newFormatter()
+	if err != nil {
+		return err
+	}
+	Observation.Formatter = formatter
+
+	// Config files may be moved from legacy locations,
+	// unless another location is set with --config
+	legacyConfigFiles := []string{}
 	if len(cfgFile) == 0 {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			log.Fatalf("error: %v", err)
 		}
 		cfgFile = filepath.Join(homeDir, ".dce", constants.DefaultConfigFileName)
+		legacyConfigFiles = append(legacyConfigFiles, utl.LegacyConfigFile(homeDir))
 	}
 
 	fsUtil := &utl.FileSystemUtil{Config: Config, ConfigFile: cfgFile}
 
+	// Upgrade config files written by older versions of dce
+	migrations, err := fsUtil.MigrateConfig(legacyConfigFiles)
+	if err != nil {
+		return fmt.Errorf("Failed to migrate configuration file: %s", err)
+	}
+	for _, migration := range migrations {
+		log.Infof("Migrated config file: %s", migration)
+	}
+
 	// Initialize config
-	// If config file does not exist,
-	// run the `dce init` command
-	if !fsUtil.IsExistingFile(cfgFile) {
-		if cmd.Name() == versionCmd.Name() {
-			return nil
-		} else if cmd.Name() != initCmd.Name() {
-			return errors.New("Config file not found. Please type 'dce init' to generate one.")
-		}
-	} else {
+	// If config file does not exist, settings may
+	// come from env vars and flags instead (eg. in CI)
+	if fsUtil.IsExistingFile(cfgFile) {
 		// Load config from the configuration file
+		// `dce config validate` reports the problems with the config file itself
 		err := fsUtil.ReadInConfig()
-		if err != nil {
+		if err != nil && cmd != configValidateCmd {
 			return fmt.Errorf("Failed to parse configuration file: %s", err)
 		}
+	} else {
+		// Config files are created with the current layout
+		Config.Version = configs.CurrentVersion
 	}
 
+	// Use the settings of the selected context
+	activeContext := *configs.Coalesce(&contextName, Config.CurrentContext, stringp(ContextEnvVar), stringp(configs.DefaultContext))
+	// Config commands may be used to fix an invalid currentContext
+	isConfigCommand := cmd == configCmd || cmd.Parent() == configCmd
+	if err := Config.UseContext(activeContext); err != nil && !isConfigCommand {
+		return svc.NewValidationError("%s", err)
+	}
+
+	// Override the config file with defaults, env vars and flags
+	if err := resolveConfig(); err != nil {
+		return svc.NewValidationError("%s", err)
+	}
 	// initialize utilities and interfaces to external things
 	Util = utl.New(Config, cfgFile, Observation)
 
 	// initialize business logic services
 	Service = svc.New(Config, Observation, Util)
+	Util.Reauthenticate = reauthenticate
 
 	return nil
 }
 
+// reauthenticate logs in to DCE again, when the API rejects
+// our credentials as expired partway through a command
+func reauthenticate() error {
+	awsConf := Config.AWS
+	if (awsConf.Profile != nil && *awsConf.Profile != "") || len(awsConf.RoleARNs) > 0 {
+		// Credentials from an AWS profile or role are refreshed by the AWS SDK
+		return nil
+	}
+	if !Util.IsInteractive() {
+		return errors.New("dce is not running interactively. Run `dce auth` to log in again")
+	}
+	log.Println("Your DCE credentials have expired. Please login again.")
+	// Logging in isn't limited by the API request timeout
+	return Service.Authenticate(rootCtx, nil)
+}
+
+// newFormatter builds the Formatter for command results
+// from the --output, --columns, --query, and --template flags
+func newFormatter() (observ.Formatter, error) {
+	if outputTemplate != "" {
+		if outputQuery != "" {
+			return nil, errors.New("--query and --template cannot be used together")
+		}
+		return observ.NewTemplateFormatter(outputTemplate)
+	}
+
+	formatter, err := observ.NewFormatter(outputFormat, outputColumns)
+	if err != nil {
+		return nil, err
+	}
+	if outputQuery != "" {
+		return observ.NewQueryFormatter(outputQuery, formatter)
+	}
+	return formatter, nil
+}
+
 // initialize anything related to logging, metrics, or tracing
 func initObservation() {
 	logrusInstance := logrus.New()
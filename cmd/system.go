@@ -17,17 +17,6 @@ var (
 	DeployConfig *service.DeployConfig
 )
 
-const (
-	DCETFInitOptionsEnvVar               string = "DCE_TF_INIT_OPTIONS"
-	DCETFApplyOptionsEnvVar              string = "DCE_TF_APPLY_OPTIONS"
-	DCEDeployLogEnvVar                   string = "DCE_DEPLOY_LOG_FILE"
-	DCELocationEnvVar                    string = "DCE_LOCATION"
-	DCEVersionEnvVar                     string = "DCE_VERSION"
-	DCENamespaceEnvVar                   string = "DCE_NAMESPACE"
-	DCEBudgetNotificationFromEmailEnvVar string = "DCE_BUDGET_NOTIFICATION_FROM_EMAIL"
-	Empty                                string = ""
-)
-
 func init() {
 	DeployConfig = &service.DeployConfig{}
 	systemDeployCmd.Flags().StringVar(&DeployConfig.Location, "local", "", "Path to a local DCE repo to deploy.")
@@ -61,11 +50,7 @@ var systemDeployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy DCE to a new master account",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Coalesce the deployment configuration values,
-		// loading in from CLI, YAML, env vars, and default values
-		DeployConfig = coalesceDeployConfig(DeployConfig)
-
-		if err := Service.Deploy(DeployConfig); err != nil {
+		if err := Service.Deploy(commandCtx, resolveDeployConfig(DeployConfig)); err != nil {
 			cmd.SilenceUsage = true
 			return err
 		}
@@ -77,73 +62,43 @@ var systemDeployCmd = &cobra.Command{
 	// though, because of cases like bad tf opts we don't want to
 	// create an usuable state.
 	PostRunE: func(cmd *cobra.Command, args []string) error {
-		return Service.PostDeploy(DeployConfig)
+		return Service.PostDeploy(commandCtx, resolveDeployConfig(DeployConfig))
 	},
 }
 
-func coalesceDeployConfig(deployConfig *service.DeployConfig) *service.DeployConfig {
-	// Before running the command, resolve the configuration by using
-	// the default load order.
-	//
-	// Config loader order:
-	//		1. CLI args
-	//		2. YAML config
-	// 		3. Env vars
-	// 		4. Default value
-	deployConfig.Location = *cfg.Coalesce(
-		&deployConfig.Location,
-		Config.Deploy.Location,
-		stringp(DCELocationEnvVar),
-		stringp(constants.DefaultDCELocation),
-	)
-	deployConfig.Version = *cfg.Coalesce(
-		&deployConfig.Version,
-		Config.Deploy.Version,
-		stringp(DCEVersionEnvVar),
-		stringp(constants.DefaultDCEVersion),
-	)
+// applyDeployDefaults sets the deploy settings whose defaults are
+// generated, and so are only applied when deploying
+func applyDeployDefaults() error {
+	return Config.ApplyDefaults(&cfg.Root{
+		Deploy: cfg.Deploy{
+			LogFile:   stringp(Util.GetLogFile()),
+			Namespace: stringp("dce-" + getRandString(8)),
+		},
+	})
+}
+
+// resolveDeployConfig returns the DeployConfig with the resolved deploy settings,
+// as Config already has the flags, env vars, config file and defaults applied
+func resolveDeployConfig(flags *service.DeployConfig) *service.DeployConfig {
+	deployConfig := *flags
+	deployConfig.Location = stringValue(Config.Deploy.Location)
 	// Normalize version ("v1.2.3" --> "1.2.3")
 	// to make our CLI more forgiving
-	deployConfig.Version = strings.TrimPrefix(deployConfig.Version, "v")
-
-	deployConfig.TFInitOptions = *cfg.Coalesce(
-		&deployConfig.TFInitOptions,
-		Config.Terraform.TFInitOptions,
-		stringp(DCETFInitOptionsEnvVar),
-		stringp(Empty),
-	)
-	deployConfig.TFApplyOptions = *cfg.Coalesce(
-		&deployConfig.TFApplyOptions,
-		Config.Terraform.TFApplyOptions,
-		stringp(DCETFApplyOptionsEnvVar),
-		stringp(Empty),
-	)
-	deployConfig.DeployLogFile = *cfg.Coalesce(
-		&deployConfig.DeployLogFile,
-		Config.Deploy.LogFile,
-		stringp(DCEDeployLogEnvVar),
-		stringp(Util.GetLogFile()),
-	)
-	deployConfig.AWSRegion = *cfg.Coalesce(
-		&deployConfig.AWSRegion,
-		Config.Deploy.AWSRegion,
-		stringp("AWS_REGION"),
-		stringp("us-east-1"),
-	)
-	deployConfig.Namespace = *cfg.Coalesce(
-		&deployConfig.Namespace,
-		Config.Deploy.Namespace,
-		stringp(DCENamespaceEnvVar),
-		stringp("dce-"+getRandString(8)),
-	)
-	deployConfig.BudgetNotificationFromEmail = *cfg.Coalesce(
-		&deployConfig.BudgetNotificationFromEmail,
-		Config.Deploy.BudgetNotificationFromEmail,
-		stringp(DCEBudgetNotificationFromEmailEnvVar),
-		stringp("no-reply@example.com"),
-	)
+	deployConfig.Version = strings.TrimPrefix(stringValue(Config.Deploy.Version), "v")
+	deployConfig.TFInitOptions = stringValue(Config.Terraform.TFInitOptions)
+	deployConfig.TFApplyOptions = stringValue(Config.Terraform.TFApplyOptions)
+	deployConfig.DeployLogFile = stringValue(Config.Deploy.LogFile)
+	deployConfig.AWSRegion = stringValue(Config.Deploy.AWSRegion)
+	deployConfig.Namespace = stringValue(Config.Deploy.Namespace)
+	deployConfig.BudgetNotificationFromEmail = stringValue(Config.Deploy.BudgetNotificationFromEmail)
+	return &deployConfig
+}
 
-	return deployConfig
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
 
 func stringp(s string) *string {
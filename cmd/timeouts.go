@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Optum/dce-cli/configs"
+	svc "github.com/Optum/dce-cli/pkg/service"
+	"github.com/spf13/cobra"
+)
+
+// defaultCommandTimeouts sets the API timeout for commands
+// which are expected to take longer than svc.DefaultAPITimeout,
+// eg. commands which request lease credentials
+var defaultCommandTimeouts = map[string]time.Duration{
+	"leases login":              20 * time.Second,
+	"leases exec":               20 * time.Second,
+	"leases shell":              20 * time.Second,
+	"leases credential-process": 20 * time.Second,
+}
+
+// apiTimeout returns the timeout for each DCE API request made by the command.
+// In order of precedence, this is the --timeout flag, the timeout configured
+// for the command, the default timeout configured, or else the built-in default.
+// Commands run with --login use the `leases login` timeouts, unless configured otherwise.
+func apiTimeout(cmd *cobra.Command, config *configs.Root) (time.Duration, error) {
+	if timeout < 0 {
+		return 0, svc.NewValidationError("--timeout must be positive, got %s", timeout)
+	}
+	if timeout > 0 {
+		return timeout, nil
+	}
+
+	names := []string{commandName(cmd)}
+	// Commands run with --login request lease credentials, like `leases login`
+	if login := cmd.Flags().Lookup("login"); login != nil && login.Value.String() == "true" {
+		names = append(names, "leases login")
+	}
+	for _, name := range names {
+		if val, ok := config.Timeouts.Commands[name]; ok {
+			return parseTimeout("timeouts.commands."+name, val)
+		}
+	}
+	if config.Timeouts.Default != nil {
+		return parseTimeout("timeouts.default", *config.Timeouts.Default)
+	}
+	for _, name := range names {
+		if val, ok := defaultCommandTimeouts[name]; ok {
+			return val, nil
+		}
+	}
+	return svc.DefaultAPITimeout, nil
+}
+
+// commandName returns the full name of the command,
+// without the root command, eg. "leases list"
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+func parseTimeout(key string, val string) (time.Duration, error) {
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, svc.NewValidationError("invalid %s config \"%s\": %s", key, val, err)
+	}
+	if duration <= 0 {
+		return 0, svc.NewValidationError("invalid %s config \"%s\": must be positive", key, val)
+	}
+	return duration, nil
+}
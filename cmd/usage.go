@@ -23,7 +23,8 @@ var usageCmd = &cobra.Command{
 	Use:   "usage",
 	Short: "View lease budget information",
 	Args:  cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		Service.GetUsage(startDate, endDate)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return Service.GetUsage(commandCtx, startDate, endDate)
 	},
 }
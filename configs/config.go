@@ -4,10 +4,34 @@ import "os"
 
 // Root contains config
 type Root struct {
+	// Version of the config file layout.
+	// Older config files are migrated to CurrentVersion.
+	Version   int `yaml:"version,omitempty"`
 	API       API
 	Region    *string
 	Deploy    Deploy `yaml:"deploy,omitempty"`
 	Terraform Terraform
+	Timeouts  Timeouts `yaml:"timeouts,omitempty"`
+	AWS       AWS      `yaml:"aws,omitempty"`
+	// AWSProfiles are the AWS CLI profiles which `dce leases login` has written
+	// lease credentials to, or `dce leases configure-profile` has configured.
+	// These are removed by `dce auth logout`.
+	AWSProfiles []string `yaml:"awsProfiles,omitempty" env:"-"`
+	// CurrentContext is the context used, unless the --context flag
+	// or DCE_CONTEXT env var is set. The top-level settings are used if unset.
+	CurrentContext *string `yaml:"currentContext,omitempty" env:"-"`
+	// Contexts are named DCE environments, eg. "dev" and "prod"
+	Contexts map[string]*Context `yaml:"contexts,omitempty"`
+
+	// activeContext is the name of the context whose settings
+	// are in the top-level fields. Empty for the default context.
+	activeContext string
+	// defaults are the top-level settings from the config file,
+	// while another context is active
+	defaults *Context
+	// overrides are settings from defaults, env vars and flags,
+	// which are not saved to the config file
+	overrides map[string]*override
 }
 
 type API struct {
@@ -16,22 +40,67 @@ type API struct {
 	// Token for authenticating against the API
 	// token is base64 encoded JSON, containing an STS token.
 	Token *string `yaml:"token,omitempty"`
+	// AuthMode sets how `dce auth` receives the API token from the auth page.
+	// May be "paste" (default), or "loopback"
+	AuthMode *string `yaml:"authMode,omitempty"`
+	// CredentialStore configures where the API token is stored
+	CredentialStore CredentialStore `yaml:"credentialStore,omitempty"`
+	// Retry configures how failed API requests are retried
+	Retry Retry `yaml:"retry,omitempty"`
+}
+
+// CredentialStore configures where the API token is stored
+type CredentialStore struct {
+	// Type may be "plaintext" (default) to store the token in this config file,
+	// "file" to store the token in an encrypted file,
+	// or "helper" to use a credential helper executable
+	Type *string `yaml:"type,omitempty"`
+	// File is the path of the encrypted token file.
+	// Defaults to ~/.dce/token.enc, or ~/.dce/token-<context>.enc for named contexts
+	File *string `yaml:"file,omitempty"`
+	// Helper is the credential helper executable, eg. "docker-credential-osxkeychain"
+	Helper *string `yaml:"helper,omitempty"`
+}
+
+// AWS configures the AWS credentials used by DCE,
+// for signing API requests, and for admin operations like `dce system deploy`
+type AWS struct {
+	// Profile is the AWS CLI profile to use, instead of the API token saved by `dce auth`.
+	// Roles, MFA and source profiles configured for the profile in ~/.aws/config are supported.
+	Profile *string `yaml:"profile,omitempty"`
+	// RoleARNs are IAM roles to assume, in order.
+	// Each role is assumed using the credentials of the previous role.
+	RoleARNs []string `yaml:"roleArns,omitempty"`
+	// MFASerial is the serial number or ARN of the MFA device used to assume the first role.
+	// The MFA token is prompted for.
+	MFASerial *string `yaml:"mfaSerial,omitempty"`
+}
+
+// Retry contains configuration for retrying API requests which fail
+// due to throttling (429), gateway errors (502, 503, 504) or network errors
+type Retry struct {
+	// Maximum number of attempts for each request, including the first. Set to 1 to disable retries.
+	MaxAttempts *int `yaml:"maxAttempts,omitempty"`
+	// Delay before the first retry, eg. "200ms". Doubles on each retry, with random jitter.
+	BaseDelay *string `yaml:"baseDelay,omitempty"`
+	// Maximum delay between retries, eg. "5s"
+	MaxDelay *string `yaml:"maxDelay,omitempty"`
 }
 
 type Deploy struct {
 	// Path to the DCE repo to deploy
 	// May be a local file path (eg. /path/to/dce)
 	// or a github repo (eg. github.com/Optum/dce)
-	Location *string `yaml:"location,omitempty"`
+	Location *string `yaml:"location,omitempty" env:"DCE_LOCATION"`
 	// Version of DCE to deploy, eg 0.12.3
-	Version *string `yaml:"version,omitempty"`
+	Version *string `yaml:"version,omitempty" env:"DCE_VERSION"`
 	// Deployment logs will be written to this location
 	LogFile *string `yaml:"logFile,omitempty"`
 	// AWS Region in which to deploy DCE
 	AWSRegion *string `yaml:"region,omitempty"`
 	// Namespace used as naming suffix for AWS resources
-	Namespace                   *string `yaml:"namespace,omitempty"`
-	BudgetNotificationFromEmail *string `yaml:"budgetNotificationFromEmail,omitempty"`
+	Namespace                   *string `yaml:"namespace,omitempty" env:"DCE_NAMESPACE"`
+	BudgetNotificationFromEmail *string `yaml:"budgetNotificationFromEmail,omitempty" env:"DCE_BUDGET_NOTIFICATION_FROM_EMAIL"`
 }
 
 // Terraform contains configuration for the underlying terraform
@@ -39,8 +108,18 @@ type Deploy struct {
 type Terraform struct {
 	Bin            *string
 	Source         *string // URL from which the Terraform release was downloaded
-	TFInitOptions  *string `yaml:"initOptions,omitempty"`
-	TFApplyOptions *string `yaml:"applyOptions,omitempty"`
+	TFInitOptions  *string `yaml:"initOptions,omitempty" env:"DCE_TF_INIT_OPTIONS"`
+	TFApplyOptions *string `yaml:"applyOptions,omitempty" env:"DCE_TF_APPLY_OPTIONS"`
+}
+
+// Timeouts contains configuration for how long to wait
+// for each DCE API request, as durations (eg. "10s")
+type Timeouts struct {
+	// Default timeout for DCE API requests
+	Default *string `yaml:"default,omitempty"`
+	// Commands overrides the timeout for specific commands,
+	// eg. {"leases list": "30s"}
+	Commands map[string]string `yaml:"commands,omitempty"`
 }
 
 var Regions = []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2"}
@@ -0,0 +1,104 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultContext is the name used for the top-level API, region, deploy
+// and terraform settings, which are used when no other context is active
+const DefaultContext = "default"
+
+// Context is a named DCE environment, eg. "dev" or "prod",
+// with its own API endpoint, token, and deployment settings
+type Context struct {
+	API       API       `yaml:"api,omitempty"`
+	Region    *string   `yaml:"region,omitempty"`
+	Deploy    Deploy    `yaml:"deploy,omitempty"`
+	Terraform Terraform `yaml:"terraform,omitempty"`
+}
+
+// UseContext activates a context, by swapping its settings into the top-level
+// API, Region, Deploy and Terraform fields used by commands.
+// Changes to these fields are saved back to the context by ForFile.
+func (c *Root) UseContext(name string) error {
+	if name == c.ActiveContext() {
+		return nil
+	}
+	if name != DefaultContext {
+		if _, ok := c.Contexts[name]; !ok {
+			return fmt.Errorf("context \"%s\" not found. Must be one of %v", name, c.ContextNames())
+		}
+	}
+
+	// Restore the top-level settings, before activating another context
+	*c = *c.ForFile()
+	if name == DefaultContext {
+		return nil
+	}
+
+	defaults := c.contextSettings()
+	c.defaults = &defaults
+	settings := c.Contexts[name]
+	if settings == nil {
+		settings = &Context{}
+	}
+	c.setContextSettings(*settings)
+	c.activeContext = name
+	return nil
+}
+
+// ActiveContext returns the name of the active context
+func (c *Root) ActiveContext() string {
+	if c.activeContext == "" {
+		return DefaultContext
+	}
+	return c.activeContext
+}
+
+// ContextNames returns the names of all contexts, including the default context
+func (c *Root) ContextNames() []string {
+	names := []string{DefaultContext}
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// ForFile returns the config as it should be written to the config file,
+// without overrides from env vars and flags, and with the settings
+// of the active context moved back into the context.
+func (c *Root) ForFile() *Root {
+	c = c.withoutOverrides()
+	if c.activeContext == "" {
+		return c
+	}
+	file := *c
+	file.Contexts = map[string]*Context{}
+	for name, ctx := range c.Contexts {
+		file.Contexts[name] = ctx
+	}
+	active := c.contextSettings()
+	file.Contexts[c.activeContext] = &active
+	file.setContextSettings(*c.defaults)
+	file.activeContext = ""
+	file.defaults = nil
+	return &file
+}
+
+func (c *Root) contextSettings() Context {
+	return Context{
+		API:       c.API,
+		Region:    c.Region,
+		Deploy:    c.Deploy,
+		Terraform: c.Terraform,
+	}
+}
+
+func (c *Root) setContextSettings(ctx Context) {
+	c.API = ctx.API
+	c.Region = ctx.Region
+	c.Deploy = ctx.Deploy
+	c.Terraform = ctx.Terraform
+}
@@ -0,0 +1,63 @@
+package configs
+
+import "reflect"
+
+// MergeChanges applies the changes made between two versions of a config
+// to another config. This allows changes made by one dce process to be
+// saved, without overwriting changes made to the config file by another.
+// Settings which were not changed are left as they are in the other config.
+func (c *Root) MergeChanges(from *Root, to *Root) {
+	mergeChanges(reflect.ValueOf(c).Elem(), reflect.ValueOf(from).Elem(), reflect.ValueOf(to).Elem())
+}
+
+func mergeChanges(dst, from, to reflect.Value) {
+	switch to.Kind() {
+	case reflect.Struct:
+		for i := 0; i < to.NumField(); i++ {
+			if to.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			mergeChanges(dst.Field(i), from.Field(i), to.Field(i))
+		}
+	case reflect.Map:
+		keys := map[interface{}]reflect.Value{}
+		for _, key := range from.MapKeys() {
+			keys[key.Interface()] = key
+		}
+		for _, key := range to.MapKeys() {
+			keys[key.Interface()] = key
+		}
+		for _, key := range keys {
+			fromEntry, toEntry := from.MapIndex(key), to.MapIndex(key)
+			if !toEntry.IsValid() {
+				// Removed
+				if !dst.IsNil() {
+					dst.SetMapIndex(key, reflect.Value{})
+				}
+				continue
+			}
+			if fromEntry.IsValid() && reflect.DeepEqual(fromEntry.Interface(), toEntry.Interface()) {
+				continue
+			}
+			if dst.IsNil() {
+				dst.Set(reflect.MakeMap(dst.Type()))
+			}
+			dstEntry := dst.MapIndex(key)
+			// Merge changes to sections, eg. contexts, setting by setting
+			isSection := toEntry.Kind() == reflect.Ptr && toEntry.Type().Elem().Kind() == reflect.Struct
+			if !isSection || !fromEntry.IsValid() || fromEntry.IsNil() || toEntry.IsNil() ||
+				!dstEntry.IsValid() || dstEntry.IsNil() {
+				dst.SetMapIndex(key, toEntry)
+				continue
+			}
+			merged := reflect.New(toEntry.Type().Elem())
+			merged.Elem().Set(dstEntry.Elem())
+			mergeChanges(merged.Elem(), fromEntry.Elem(), toEntry.Elem())
+			dst.SetMapIndex(key, merged)
+		}
+	default:
+		if !reflect.DeepEqual(from.Interface(), to.Interface()) {
+			dst.Set(to)
+		}
+	}
+}
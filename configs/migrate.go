@@ -0,0 +1,150 @@
+package configs
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CurrentVersion is the version of the config file layout written by this
+// version of dce. Add a Migration when changing the layout.
+const CurrentVersion = 1
+
+// Migration upgrades a config file from the previous version
+type Migration struct {
+	// Version is the version of the config file after migrating
+	Version int
+	// Description is logged when the migration is applied
+	Description string
+	Migrate     func(doc yaml.MapSlice) (yaml.MapSlice, error)
+}
+
+// Migrations upgrade config files from older versions of dce, in order
+var Migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "removed settings which are no longer used, and fixed the case of setting names",
+		Migrate: func(doc yaml.MapSlice) (yaml.MapSlice, error) {
+			// Master account credentials and the GitHub token
+			// were removed in v0.2.0 and v0.3.1
+			doc = removeKeys(doc, "system", "masterAccountCreds", "githubToken")
+			return normalizeKeys(doc, reflect.TypeOf(Root{})), nil
+		},
+	},
+}
+
+// Migrate upgrades a config file to CurrentVersion,
+// returning the migrated config file, and the descriptions
+// of the migrations which changed it. Config files which
+// no migration changes are returned unchanged.
+// Config files which are not valid YAML are returned unchanged.
+func Migrate(data []byte) ([]byte, []string, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		// Leave invalid config files as they are,
+		// so the error is reported when reading the config
+		return data, nil, nil
+	}
+
+	version := 0
+	for _, item := range doc {
+		if key, ok := item.Key.(string); ok && key == "version" {
+			v, ok := item.Value.(int)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid config version \"%v\": must be an integer", item.Value)
+			}
+			version = v
+		}
+	}
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("config file version %d is newer than this version of dce supports (%d). Please upgrade dce", version, CurrentVersion)
+	}
+
+	applied := []string{}
+	for _, migration := range Migrations {
+		if migration.Version <= version {
+			continue
+		}
+		before, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		doc, err = migration.Migrate(doc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate config to version %d: %s", migration.Version, err)
+		}
+		// Only report migrations which changed the config file,
+		// so that it isn't rewritten and backed up for nothing
+		after, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !bytes.Equal(before, after) {
+			applied = append(applied, migration.Description)
+		}
+	}
+	if len(applied) == 0 {
+		return data, applied, nil
+	}
+
+	// Put the version first, so it's easy to find
+	doc = append(yaml.MapSlice{{Key: "version", Value: CurrentVersion}}, removeKeys(doc, "version")...)
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrated, applied, nil
+}
+
+// removeKeys removes keys from a YAML mapping, ignoring case
+func removeKeys(doc yaml.MapSlice, keys ...string) yaml.MapSlice {
+	kept := yaml.MapSlice{}
+	for _, item := range doc {
+		remove := false
+		for _, key := range keys {
+			if name, ok := item.Key.(string); ok && strings.EqualFold(name, key) {
+				remove = true
+			}
+		}
+		if !remove {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// normalizeKeys renames keys which differ only by case from the settings
+// of a config struct, eg. "basePath" to "basepath", as keys are case sensitive
+func normalizeKeys(doc yaml.MapSlice, typ reflect.Type) yaml.MapSlice {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for i, item := range doc {
+		name, ok := item.Key.(string)
+		if !ok {
+			continue
+		}
+		switch typ.Kind() {
+		case reflect.Struct:
+			for j := 0; j < typ.NumField(); j++ {
+				field := typ.Field(j)
+				if field.PkgPath != "" || !strings.EqualFold(yamlName(field), name) {
+					continue
+				}
+				doc[i].Key = yamlName(field)
+				if nested, ok := item.Value.(yaml.MapSlice); ok {
+					doc[i].Value = normalizeKeys(nested, field.Type)
+				}
+			}
+		case reflect.Map:
+			// Map keys are names, eg. of contexts, so are not renamed
+			if nested, ok := item.Value.(yaml.MapSlice); ok {
+				doc[i].Value = normalizeKeys(nested, typ.Elem())
+			}
+		}
+	}
+	return doc
+}
@@ -0,0 +1,269 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Sources of config values, in order of precedence from lowest to highest.
+// Values from the config file are overridden by env vars,
+// which are overridden by command line flags.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// SettingSource describes where the value of a setting came from,
+// for --show-config-sources
+type SettingSource struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	// Name of the env var or flag which set the value, if any
+	Name string `json:"name,omitempty"`
+}
+
+// override is a value which did not come from the config file
+type override struct {
+	source string
+	name   string
+	// fileVal is the value from the config file, which is restored
+	// by ForFile, so that overrides are not saved to the config file
+	fileVal reflect.Value
+	val     reflect.Value
+}
+
+// ApplyDefaults sets the settings which are not set in the config file
+// to their default values
+func (c *Root) ApplyDefaults(defaults *Root) error {
+	for _, key := range settingKeys() {
+		val, err := defaults.Get(key)
+		if err != nil {
+			return err
+		}
+		current, err := c.Get(key)
+		if err != nil {
+			return err
+		}
+		if val == nil || current != nil {
+			continue
+		}
+		if err := c.Override(key, settingString(val), SourceDefault, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyEnv overrides settings with the DCE_* env vars which are set.
+// Use EnvVar for the name of the env var for a setting.
+func (c *Root) ApplyEnv(lookupEnv func(key string) (string, bool)) error {
+	for _, key := range settingKeys() {
+		name := EnvVar(key)
+		if val, ok := lookupEnv(name); ok && val != "" {
+			if err := c.Override(key, val, SourceEnv, name); err != nil {
+				return fmt.Errorf("invalid %s env var: %s", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Override sets a setting for this run of dce, without saving it to
+// the config file. source is one of the Source* constants, and name
+// is the name of the env var or flag which set it.
+func (c *Root) Override(key string, value string, source string, name string) error {
+	if err := validateSetting(key, value); err != nil {
+		return err
+	}
+	s, err := c.findSetting(key, true)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseSetting(s.val.Type(), value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, err)
+	}
+
+	if c.overrides == nil {
+		c.overrides = map[string]*override{}
+	}
+	fileVal := reflect.New(s.val.Type()).Elem()
+	fileVal.Set(s.val)
+	if prev, ok := c.overrides[key]; ok {
+		fileVal = prev.fileVal
+	}
+	c.overrides[key] = &override{source: source, name: name, fileVal: fileVal, val: parsed}
+	s.val.Set(parsed)
+	return nil
+}
+
+// Sources returns the settings which are set, and where their values came from.
+// API tokens are redacted.
+func (c *Root) Sources() []*SettingSource {
+	fileSource := SourceFile
+	if c.ActiveContext() != DefaultContext {
+		fileSource = fmt.Sprintf("%s (context %s)", SourceFile, c.ActiveContext())
+	}
+
+	sources := []*SettingSource{}
+	for _, key := range settingKeys() {
+		val, err := c.Get(key)
+		if err != nil || val == nil {
+			continue
+		}
+		source := &SettingSource{Key: key, Value: settingString(val), Source: fileSource}
+		if o, ok := c.overrides[key]; ok && isSameValue(o.val, c.mustFind(key)) {
+			source.Source = o.source
+			source.Name = o.name
+		}
+		if strings.HasSuffix(key, ".token") {
+			source.Value = "REDACTED"
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// IsOverridden returns true if the setting was set by a default,
+// env var or flag, rather than the config file
+func (c *Root) IsOverridden(key string) bool {
+	o, ok := c.overrides[key]
+	return ok && isSameValue(o.val, c.mustFind(key))
+}
+
+// withoutOverrides returns a copy of the config, with the
+// values from the config file restored in place of overrides,
+// unless they have been changed since
+func (c *Root) withoutOverrides() *Root {
+	if len(c.overrides) == 0 {
+		return c
+	}
+	file := *c
+	for key, o := range c.overrides {
+		s, err := file.findSetting(key, false)
+		if err != nil || !isSameValue(o.val, s.val) {
+			continue
+		}
+		s.val.Set(o.fileVal)
+		s.store()
+	}
+	file.overrides = nil
+	return &file
+}
+
+// mustFind returns the value of a setting known to exist
+func (c *Root) mustFind(key string) reflect.Value {
+	s, err := c.findSetting(key, false)
+	if err != nil {
+		return reflect.Value{}
+	}
+	return s.val
+}
+
+// isSameValue checks if a setting still holds the value it was overridden with.
+// Pointers are compared, so that values set by commands are saved to the
+// config file, even if they are equal to the overridden value.
+func isSameValue(overridden reflect.Value, current reflect.Value) bool {
+	if !current.IsValid() || current.Kind() != overridden.Kind() {
+		return false
+	}
+	switch current.Kind() {
+	case reflect.Ptr:
+		return current.Pointer() == overridden.Pointer()
+	case reflect.Slice:
+		return current.Pointer() == overridden.Pointer() && current.Len() == overridden.Len()
+	}
+	return false
+}
+
+// settingKeys returns the keys of all settings which may be
+// overridden, in the order they appear in the config.
+// Maps, and fields tagged `env:"-"`, are not included.
+func settingKeys() []string {
+	keys := []string{}
+	var walk func(typ reflect.Type, prefix string)
+	walk = func(typ reflect.Type, prefix string) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" || field.Tag.Get("env") == "-" {
+				continue
+			}
+			key := yamlName(field)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				walk(field.Type, key)
+			case reflect.Ptr, reflect.Slice:
+				keys = append(keys, key)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Root{}), "")
+	return keys
+}
+
+// EnvVar returns the name of the env var which overrides a setting,
+// eg. DCE_API_HOST for "api.host". Settings may set the name of their
+// env var with an `env` struct tag.
+func EnvVar(key string) string {
+	typ := reflect.TypeOf(Root{})
+	names := []string{"DCE"}
+	for _, part := range strings.Split(key, ".") {
+		var field reflect.StructField
+		found := false
+		for i := 0; typ.Kind() == reflect.Struct && i < typ.NumField(); i++ {
+			if strings.EqualFold(yamlName(typ.Field(i)), part) {
+				field, found = typ.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return ""
+		}
+		if name := field.Tag.Get("env"); name != "" {
+			return name
+		}
+		// Untagged fields use the Go field name, so that
+		// eg. BasePath becomes BASE_PATH rather than BASEPATH
+		name := field.Name
+		if field.Tag.Get("yaml") != "" {
+			name = yamlName(field)
+		}
+		names = append(names, screamingSnakeCase(name))
+		typ = field.Type
+	}
+	return strings.Join(names, "_")
+}
+
+// screamingSnakeCase converts eg. "basePath" or "BasePath" to "BASE_PATH",
+// and "roleArns" or "AWSRegion" to "ROLE_ARNS" and "AWS_REGION"
+func screamingSnakeCase(name string) string {
+	runes := []rune(name)
+	var out strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				out.WriteRune('_')
+			}
+		}
+		out.WriteRune(unicode.ToUpper(r))
+	}
+	return out.String()
+}
+
+// settingString formats a setting value, as it would be passed to Set
+func settingString(val interface{}) string {
+	if list, ok := val.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(val)
+}
@@ -0,0 +1,496 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Values of api.authMode
+const (
+	AuthModePaste    = "paste"
+	AuthModeLoopback = "loopback"
+)
+
+// AuthModes are the valid values of api.authMode
+var AuthModes = []string{AuthModePaste, AuthModeLoopback}
+
+// Values of api.credentialStore.type
+const (
+	CredentialStorePlaintext = "plaintext"
+	CredentialStoreFile      = "file"
+	CredentialStoreHelper    = "helper"
+)
+
+// CredentialStoreTypes are the valid values of api.credentialStore.type
+var CredentialStoreTypes = []string{CredentialStorePlaintext, CredentialStoreFile, CredentialStoreHelper}
+
+// settingValidators check the values of settings, by key.
+// Keys are lower case, and relative to a context,
+// so "region" also validates "contexts.prod.region".
+// "*" matches any map key.
+// deploy.region is not checked, as DCE may be deployed to any AWS region.
+var settingValidators = map[string]func(val string) error{
+	"region":                   oneOf(Regions),
+	"api.authmode":             oneOf(AuthModes),
+	"api.credentialstore.type": oneOf(CredentialStoreTypes),
+	"api.retry.maxattempts":    minInt(1),
+	"api.retry.basedelay":      positiveDuration,
+	"api.retry.maxdelay":       positiveDuration,
+	"timeouts.default":         positiveDuration,
+	"timeouts.commands.*":      positiveDuration,
+}
+
+// Get returns the value of a setting, by its dot separated YAML key
+// (eg. "api.host", or "contexts.prod.region").
+// Returns nil if the setting is not set.
+func (c *Root) Get(key string) (interface{}, error) {
+	s, err := c.findSetting(key, false)
+	if err != nil {
+		return nil, err
+	}
+	if !s.val.IsValid() || isEmptyValue(s.val) {
+		return nil, nil
+	}
+	if s.val.Kind() == reflect.Ptr {
+		return s.val.Elem().Interface(), nil
+	}
+	return s.val.Interface(), nil
+}
+
+// Set parses a value for a setting, by its dot separated YAML key.
+// Lists are comma separated. The value is saved to the config file,
+// even if the setting was overridden.
+func (c *Root) Set(key string, value string) error {
+	if err := validateSetting(key, value); err != nil {
+		return err
+	}
+	s, err := c.findSetting(key, true)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseSetting(s.val.Type(), value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, err)
+	}
+	s.val.Set(parsed)
+	s.store()
+	delete(c.overrides, key)
+	return nil
+}
+
+// Unset clears a setting, by its dot separated YAML key
+func (c *Root) Unset(key string) error {
+	s, err := c.findSetting(key, false)
+	if err != nil {
+		return err
+	}
+	if s.val.IsValid() {
+		s.val.Set(reflect.Zero(s.val.Type()))
+		s.store()
+	}
+	return nil
+}
+
+// setting is a value in the config, found by findSetting
+type setting struct {
+	val reflect.Value
+	// store writes the value back to its map, for map entries,
+	// which are not settable in place
+	store func()
+}
+
+// findSetting walks the config by YAML key, and returns the setting.
+// If create is true, missing map entries are created.
+// Otherwise, an invalid value is returned for missing settings.
+func (c *Root) findSetting(key string, create bool) (*setting, error) {
+	if key == "" {
+		return nil, fmt.Errorf("config key is required")
+	}
+	parts := strings.Split(key, ".")
+	val := reflect.ValueOf(c).Elem()
+	for i := 0; i < len(parts); i++ {
+		switch val.Kind() {
+		case reflect.Struct:
+			field, ok := structField(val, parts[i])
+			if !ok {
+				return nil, fmt.Errorf("unknown config key \"%s\". Must be one of %v",
+					strings.Join(parts[:i+1], "."), fieldNames(val.Type(), strings.Join(parts[:i], ".")))
+			}
+			val = field
+		case reflect.Map:
+			// Map keys may contain dots, eg. command names in timeouts.commands,
+			// so the rest of the key is used for maps of values
+			mapKey := parts[i]
+			isSection := val.Type().Elem().Kind() == reflect.Ptr
+			if !isSection {
+				mapKey = strings.Join(parts[i:], ".")
+				i = len(parts) - 1
+			}
+			if val.IsNil() {
+				if !create {
+					return &setting{store: func() {}}, nil
+				}
+				val.Set(reflect.MakeMap(val.Type()))
+			}
+			entry := val.MapIndex(reflect.ValueOf(mapKey))
+			if !entry.IsValid() {
+				if !create {
+					return &setting{store: func() {}}, nil
+				}
+				entry = reflect.Zero(val.Type().Elem())
+				if isSection {
+					entry = reflect.New(val.Type().Elem().Elem())
+					val.SetMapIndex(reflect.ValueOf(mapKey), entry)
+				}
+			}
+			if i == len(parts)-1 {
+				return newMapEntry(val, mapKey, entry), nil
+			}
+			if entry.IsNil() {
+				entry = reflect.New(val.Type().Elem().Elem())
+				val.SetMapIndex(reflect.ValueOf(mapKey), entry)
+			}
+			// Step into the section
+			val = entry.Elem()
+		default:
+			return nil, fmt.Errorf("unknown config key \"%s\": %s is not a section", key, strings.Join(parts[:i], "."))
+		}
+	}
+	return &setting{val: val, store: func() {}}, nil
+}
+
+// newMapEntry returns a settable copy of a map entry,
+// which is stored in the map, or removed from it if empty
+func newMapEntry(m reflect.Value, key string, entry reflect.Value) *setting {
+	val := reflect.New(m.Type().Elem()).Elem()
+	val.Set(entry)
+	return &setting{val: val, store: func() {
+		if isEmptyValue(val) {
+			m.SetMapIndex(reflect.ValueOf(key), reflect.Value{})
+			return
+		}
+		m.SetMapIndex(reflect.ValueOf(key), val)
+	}}
+}
+
+// structField finds a struct field by its YAML name, ignoring case
+func structField(val reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if strings.EqualFold(yamlName(field), name) {
+			return val.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// fieldNames returns the keys of the settings in a section
+func fieldNames(typ reflect.Type, prefix string) []string {
+	names := []string{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := yamlName(field)
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// yamlName returns the key used for a struct field in the config file
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		// yaml.v2 defaults to the lower cased field name
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// parseSetting parses a string into a value of the setting's type
+func parseSetting(typ reflect.Type, value string) (reflect.Value, error) {
+	switch typ.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(value).Convert(typ), nil
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("\"%s\" is not an integer", value)
+		}
+		return reflect.ValueOf(i).Convert(typ), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("\"%s\" is not true or false", value)
+		}
+		return reflect.ValueOf(b).Convert(typ), nil
+	case reflect.Ptr:
+		elem, err := parseSetting(typ.Elem(), value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(typ, 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			elem, err := parseSetting(typ.Elem(), item)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		return slice, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("is a section. Set its settings instead")
+	}
+}
+
+// validateSetting checks the value of a setting
+// against the rules in settingValidators
+func validateSetting(key string, value string) error {
+	validator, ok := settingValidators[validatorKey(key)]
+	if !ok {
+		return nil
+	}
+	if err := validator(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, err)
+	}
+	return nil
+}
+
+// validatorKey normalizes a setting key, for looking up its validator
+func validatorKey(key string) string {
+	parts := strings.Split(strings.ToLower(key), ".")
+	// Context settings use the same rules as top-level settings
+	if len(parts) > 2 && parts[0] == "contexts" {
+		parts = parts[2:]
+	}
+	if len(parts) > 2 && parts[0] == "timeouts" && parts[1] == "commands" {
+		parts = []string{"timeouts", "commands", "*"}
+	}
+	return strings.Join(parts, ".")
+}
+
+func oneOf(allowed []string) func(val string) error {
+	return func(val string) error {
+		for _, a := range allowed {
+			if val == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("\"%s\" must be one of %v", val, allowed)
+	}
+}
+
+func minInt(min int) func(val string) error {
+	return func(val string) error {
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("\"%s\" is not an integer", val)
+		}
+		if i < min {
+			return fmt.Errorf("%d must be at least %d", i, min)
+		}
+		return nil
+	}
+}
+
+func positiveDuration(val string) error {
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return fmt.Errorf("\"%s\" is not a duration (eg. \"10s\")", val)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("\"%s\" must be positive", val)
+	}
+	return nil
+}
+
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return val.IsNil()
+	case reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	}
+	return false
+}
+
+// ValidationProblem is a problem found in a config file by Validate
+type ValidationProblem struct {
+	// Line of the config file, or 0 if unknown
+	Line    int
+	Key     string
+	Message string
+}
+
+func (p *ValidationProblem) Error() string {
+	msg := p.Message
+	if p.Key != "" {
+		msg = p.Key + ": " + msg
+	}
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s", p.Line, msg)
+	}
+	return msg
+}
+
+var yamlErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// Validate checks the contents of a config file,
+// for unknown keys, invalid types, and invalid values
+func Validate(data []byte) []*ValidationProblem {
+	problems := []*ValidationProblem{}
+	var config Root
+	err := yaml.UnmarshalStrict(data, &config)
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		for _, msg := range typeErr.Errors {
+			problem := &ValidationProblem{Message: msg}
+			if match := yamlErrorLine.FindStringSubmatch(msg); match != nil {
+				problem.Line, _ = strconv.Atoi(match[1])
+				problem.Message = match[2]
+			}
+			problems = append(problems, problem)
+		}
+	} else if err != nil {
+		problem := &ValidationProblem{Message: err.Error()}
+		if match := yamlErrorLine.FindStringSubmatch(strings.TrimPrefix(err.Error(), "yaml: ")); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = match[2]
+		}
+		return append(problems, problem)
+	}
+
+	// Check the values of settings
+	values := map[string]string{}
+	flattenSettings(reflect.ValueOf(config), "", values)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := validateSetting(key, values[key]); err != nil {
+			problems = append(problems, &ValidationProblem{
+				Line:    yamlKeyLine(data, key),
+				Key:     key,
+				Message: strings.TrimPrefix(err.Error(), "invalid value for "+key+": "),
+			})
+		}
+	}
+
+	if config.CurrentContext != nil && *config.CurrentContext != "" && *config.CurrentContext != DefaultContext {
+		if _, ok := config.Contexts[*config.CurrentContext]; !ok {
+			problems = append(problems, &ValidationProblem{
+				Line:    yamlKeyLine(data, "currentContext"),
+				Key:     "currentContext",
+				Message: fmt.Sprintf("context \"%s\" not found. Must be one of %v", *config.CurrentContext, config.ContextNames()),
+			})
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
+}
+
+// flattenSettings collects the string values of settings, by key
+func flattenSettings(val reflect.Value, prefix string, values map[string]string) {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+	switch val.Kind() {
+	case reflect.Ptr:
+		if !val.IsNil() {
+			flattenSettings(val.Elem(), prefix, values)
+		}
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			flattenSettings(val.Field(i), join(yamlName(field)), values)
+		}
+	case reflect.Map:
+		for _, key := range val.MapKeys() {
+			flattenSettings(val.MapIndex(key), join(key.String()), values)
+		}
+	case reflect.String:
+		// Empty values are treated as unset
+		if val.String() != "" {
+			values[prefix] = val.String()
+		}
+	case reflect.Int:
+		values[prefix] = strconv.Itoa(int(val.Int()))
+	}
+}
+
+// yamlKeyLine returns the line number of a dot separated key
+// in a block style YAML document, or 0 if it is not found
+func yamlKeyLine(data []byte, key string) int {
+	parts := strings.Split(key, ".")
+	type entry struct {
+		indent int
+		key    string
+	}
+	stack := []entry{}
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		colon := strings.Index(trimmed, ":")
+		if colon < 0 {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, entry{indent, strings.Trim(trimmed[:colon], `"'`)})
+
+		if len(stack) > len(parts) {
+			continue
+		}
+		// Map keys may contain dots, so the last key on the
+		// stack may match the rest of the key
+		matched := true
+		for j, e := range stack {
+			if j == len(stack)-1 {
+				matched = strings.EqualFold(e.key, strings.Join(parts[j:], "."))
+			} else if !strings.EqualFold(e.key, parts[j]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i + 1
+		}
+	}
+	return 0
+}
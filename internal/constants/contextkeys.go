@@ -5,4 +5,5 @@ type ContextKey string
 const (
 	DeployConfig     ContextKey = ContextKey("deployConfig")
 	DeployLogFileKey ContextKey = ContextKey("deployLogFile")
+	APITimeoutKey    ContextKey = ContextKey("apiTimeout")
 )
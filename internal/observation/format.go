@@ -0,0 +1,426 @@
+package observation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/Optum/dce-cli/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats supported by the `--output` flag
+const (
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatTable = "table"
+	FormatCSV   = "csv"
+)
+
+// Formats lists all of the supported output formats
+var Formats = []string{FormatJSON, FormatYAML, FormatTable, FormatCSV}
+
+// Formatter renders API payloads for display
+type Formatter interface {
+	Format(data interface{}) ([]byte, error)
+}
+
+// NewFormatter returns a Formatter for the given output format.
+// `columns` selects which fields are rendered by the table and csv formats.
+// If no columns are provided, a default set of columns is used.
+func NewFormatter(format string, columns []string) (Formatter, error) {
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return &JSONFormatter{}, nil
+	case FormatYAML:
+		return &YAMLFormatter{}, nil
+	case FormatTable:
+		return &TableFormatter{Columns: columns}, nil
+	case FormatCSV:
+		return &CSVFormatter{Columns: columns}, nil
+	default:
+		return nil, fmt.Errorf("invalid output format \"%s\": must be one of %s",
+			format, strings.Join(Formats, ", "))
+	}
+}
+
+// NewQueryFormatter returns a Formatter which filters payloads
+// using a JSONPath expression, before rendering the result with `next`
+func NewQueryFormatter(query string, next Formatter) (Formatter, error) {
+	path, err := ParseJSONPath(query)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryFormatter{Path: path, Next: next}, nil
+}
+
+// QueryFormatter filters payloads using a JSONPath expression
+type QueryFormatter struct {
+	Path *JSONPath
+	Next Formatter
+}
+
+func (f *QueryFormatter) Format(data interface{}) ([]byte, error) {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+	return f.Next.Format(f.Path.Evaluate(generic))
+}
+
+// NewTemplateFormatter returns a Formatter which renders payloads
+// using a Go text/template.
+func NewTemplateFormatter(tmpl string) (Formatter, error) {
+	t, err := template.New("output").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %s", err)
+	}
+	return &TemplateFormatter{Template: t}, nil
+}
+
+// TemplateFormatter renders payloads using a Go text/template.
+// Templates are executed against the payload structs,
+// so fields are referenced by their Go names (eg. `{{.ExpiresOn}}`)
+type TemplateFormatter struct {
+	Template *template.Template
+}
+
+func (f *TemplateFormatter) Format(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.Template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Functions available within `--template` templates
+var templateFuncs = template.FuncMap{
+	// json renders a value as JSON
+	"json": func(val interface{}) (string, error) {
+		valJSON, err := json.Marshal(val)
+		return string(valJSON), err
+	},
+	// int renders a number without a decimal or exponent,
+	// eg. `{{int .ExpiresOn}}`
+	"int": func(val float64) int64 {
+		return int64(val)
+	},
+	// date renders an epoch timestamp as an RFC3339 date,
+	// eg. `{{date .ExpiresOn}}`
+	"date": func(val float64) string {
+		return time.Unix(int64(val), 0).UTC().Format(time.RFC3339)
+	},
+}
+
+// JSONFormatter renders payloads as indented JSON
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) Format(data interface{}) ([]byte, error) {
+	return json.MarshalIndent(data, "", "\t")
+}
+
+// YAMLFormatter renders payloads as YAML,
+// using the same field names as the JSON output
+type YAMLFormatter struct{}
+
+func (f *YAMLFormatter) Format(data interface{}) ([]byte, error) {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(generic)
+}
+
+// TableFormatter renders payloads as whitespace-aligned columns
+type TableFormatter struct {
+	Columns []string
+}
+
+func (f *TableFormatter) Format(data interface{}) ([]byte, error) {
+	columns, rows, err := tabulate(data, f.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = formatCell(row[col.Field], col.Timestamp)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CSVFormatter renders payloads as comma separated values,
+// with a header row
+type CSVFormatter struct {
+	Columns []string
+}
+
+func (f *CSVFormatter) Format(data interface{}) ([]byte, error) {
+	columns, rows, err := tabulate(data, f.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Field
+	}
+	if err := w.Write(headers); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, col := range columns {
+			// Keep raw values in CSV output, so they're easy to parse
+			record[i] = formatCell(row[col.Field], false)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Column describes a single field rendered by the table and csv formats
+type Column struct {
+	// Header displayed in table output, eg. "ACCOUNT ID"
+	Header string
+	// Field is the JSON field name, eg. "accountId"
+	Field string
+	// Timestamp fields contain epoch times, and are displayed as dates in tables
+	Timestamp bool
+}
+
+// Default columns for each of the DCE models,
+// used when no columns are selected
+var (
+	LeaseDefaultColumns   = []string{"id", "accountId", "principalId", "leaseStatus", "budgetAmount", "budgetCurrency", "expiresOn"}
+	AccountDefaultColumns = []string{"id", "accountStatus", "adminRoleArn", "lastModifiedOn"}
+	UsageDefaultColumns   = []string{"accountId", "principalId", "costAmount", "costCurrency", "startDate", "endDate"}
+)
+
+// modelColumns returns all available columns, and the default columns
+// for the type of the given data
+func modelColumns(data interface{}) ([]Column, []string) {
+	t := reflect.TypeOf(data)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(models.Lease{}):
+		return structColumns(t), LeaseDefaultColumns
+	case reflect.TypeOf(models.Account{}):
+		return structColumns(t), AccountDefaultColumns
+	case reflect.TypeOf(models.Usage{}):
+		return structColumns(t), UsageDefaultColumns
+	}
+	if t != nil && t.Kind() == reflect.Struct {
+		columns := structColumns(t)
+		return columns, columnFields(columns)
+	}
+	return nil, nil
+}
+
+// structColumns builds columns from the JSON tags of a struct type
+func structColumns(t reflect.Type) []Column {
+	var columns []Column
+	for i := 0; i < t.NumField(); i++ {
+		field := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if field == "" || field == "-" {
+			continue
+		}
+		columns = append(columns, newColumn(field))
+	}
+	return columns
+}
+
+func newColumn(field string) Column {
+	var words []string
+	start := 0
+	for i, r := range field {
+		if i > 0 && unicode.IsUpper(r) {
+			words = append(words, field[start:i])
+			start = i
+		}
+	}
+	words = append(words, field[start:])
+
+	return Column{
+		Header:    strings.ToUpper(strings.Join(words, " ")),
+		Field:     field,
+		Timestamp: strings.HasSuffix(field, "On") || strings.HasSuffix(field, "Date"),
+	}
+}
+
+func columnFields(columns []Column) []string {
+	fields := make([]string, len(columns))
+	for i, col := range columns {
+		fields[i] = col.Field
+	}
+	return fields
+}
+
+// tabulate converts the data into rows of JSON fields,
+// and resolves the selected columns
+func tabulate(data interface{}, selected []string) ([]Column, []map[string]interface{}, error) {
+	available, defaults := modelColumns(data)
+
+	generic, err := toGeneric(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []map[string]interface{}
+	switch val := generic.(type) {
+	case []interface{}:
+		for _, item := range val {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				row = map[string]interface{}{"value": item}
+			}
+			rows = append(rows, row)
+		}
+	case map[string]interface{}:
+		rows = append(rows, val)
+	case nil:
+	default:
+		rows = append(rows, map[string]interface{}{"value": val})
+	}
+
+	// Fall back to the fields found in the data itself,
+	// for payloads that aren't DCE models
+	if available == nil {
+		seen := map[string]bool{}
+		for _, row := range rows {
+			for field := range row {
+				if !seen[field] {
+					seen[field] = true
+					available = append(available, newColumn(field))
+				}
+			}
+		}
+		sort.Slice(available, func(i, j int) bool { return available[i].Field < available[j].Field })
+		defaults = columnFields(available)
+	}
+
+	if len(selected) == 0 {
+		selected = defaults
+	}
+
+	var columns []Column
+	for _, name := range selected {
+		col, ok := findColumn(available, name)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid column \"%s\": must be one of %s",
+				name, strings.Join(columnFields(available), ", "))
+		}
+		columns = append(columns, col)
+	}
+
+	return columns, rows, nil
+}
+
+func findColumn(columns []Column, name string) (Column, bool) {
+	name = strings.TrimSpace(name)
+	for _, col := range columns {
+		if strings.EqualFold(col.Field, name) || strings.EqualFold(col.Header, name) {
+			return col, true
+		}
+	}
+	return Column{}, false
+}
+
+// toGeneric converts the data to generic maps and slices,
+// keyed by JSON field names
+func toGeneric(data interface{}) (interface{}, error) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(dataJSON))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(generic), nil
+}
+
+// normalizeNumbers converts json.Number values to int64 or float64,
+// so epoch timestamps aren't rendered in scientific notation
+func normalizeNumbers(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			if f == float64(int64(f)) {
+				return int64(f)
+			}
+			return f
+		}
+		return v.String()
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = normalizeNumbers(v[k])
+		}
+	}
+	return val
+}
+
+func formatCell(val interface{}, timestamp bool) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case int64:
+		if timestamp && v > 0 {
+			return time.Unix(v, 0).UTC().Format(time.RFC3339)
+		}
+		return fmt.Sprintf("%d", v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatCell(item, timestamp)
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		valJSON, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(valJSON)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,340 @@
+package observation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed JSONPath expression, eg. `$[*].accountId`
+//
+// Supported syntax:
+//
+//	$              the root object
+//	.name, ['name'] child fields
+//	.*, [*]        all children
+//	..name, ..*    recursive descent
+//	[0], [-1]      array indexes
+//	[0,2], ['a','b'] unions
+//	[1:3]          array slices
+type JSONPath struct {
+	expr     string
+	segments []pathSegment
+}
+
+type segmentKind int
+
+const (
+	fieldSegment segmentKind = iota
+	wildcardSegment
+	indexSegment
+	sliceSegment
+	unionSegment
+)
+
+type pathSegment struct {
+	kind      segmentKind
+	recursive bool
+	names     []string
+	indexes   []int
+	// slice bounds. nil means "open ended"
+	start, end *int
+}
+
+// definite segments select at most a single value
+func (s pathSegment) definite() bool {
+	return !s.recursive && (s.kind == fieldSegment || s.kind == indexSegment)
+}
+
+// ParseJSONPath parses a JSONPath expression
+func ParseJSONPath(expr string) (*JSONPath, error) {
+	p := &JSONPath{expr: expr}
+	rest := strings.TrimSpace(expr)
+	rest = strings.TrimPrefix(rest, "$")
+
+	for len(rest) > 0 {
+		var seg pathSegment
+		var err error
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg, rest, err = parseDotSegment(rest[2:])
+			seg.recursive = true
+		case strings.HasPrefix(rest, "."):
+			seg, rest, err = parseDotSegment(rest[1:])
+		case strings.HasPrefix(rest, "["):
+			seg, rest, err = parseBracketSegment(rest[1:])
+		default:
+			err = fmt.Errorf("unexpected \"%s\"", rest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath \"%s\": %s", expr, err)
+		}
+		p.segments = append(p.segments, seg)
+	}
+
+	return p, nil
+}
+
+func parseDotSegment(rest string) (pathSegment, string, error) {
+	if strings.HasPrefix(rest, "*") {
+		return pathSegment{kind: wildcardSegment}, rest[1:], nil
+	}
+	if strings.HasPrefix(rest, "[") {
+		// Allow `..[0]` and `..['name']`
+		return parseBracketSegment(rest[1:])
+	}
+	end := strings.IndexAny(rest, ".[")
+	if end == -1 {
+		end = len(rest)
+	}
+	name := rest[:end]
+	if name == "" {
+		return pathSegment{}, "", fmt.Errorf("missing field name")
+	}
+	return pathSegment{kind: fieldSegment, names: []string{name}}, rest[end:], nil
+}
+
+func parseBracketSegment(rest string) (pathSegment, string, error) {
+	end := closingBracket(rest)
+	if end == -1 {
+		return pathSegment{}, "", fmt.Errorf("missing \"]\"")
+	}
+	body := strings.TrimSpace(rest[:end])
+	rest = rest[end+1:]
+
+	switch {
+	case body == "*":
+		return pathSegment{kind: wildcardSegment}, rest, nil
+	case strings.HasPrefix(body, "?") || strings.HasPrefix(body, "("):
+		return pathSegment{}, "", fmt.Errorf("filter and script expressions are not supported")
+	case strings.Contains(body, ":"):
+		seg, err := parseSlice(body)
+		return seg, rest, err
+	}
+
+	var names []string
+	var indexes []int
+	for _, item := range splitUnion(body) {
+		item = strings.TrimSpace(item)
+		if len(item) >= 2 && (item[0] == '\'' || item[0] == '"') && item[len(item)-1] == item[0] {
+			names = append(names, item[1:len(item)-1])
+			continue
+		}
+		idx, err := strconv.Atoi(item)
+		if err != nil {
+			return pathSegment{}, "", fmt.Errorf("invalid index \"%s\"", item)
+		}
+		indexes = append(indexes, idx)
+	}
+	if len(names) > 0 && len(indexes) > 0 {
+		return pathSegment{}, "", fmt.Errorf("cannot mix field names and indexes in \"[%s]\"", body)
+	}
+
+	switch {
+	case len(names) == 1:
+		return pathSegment{kind: fieldSegment, names: names}, rest, nil
+	case len(indexes) == 1:
+		return pathSegment{kind: indexSegment, indexes: indexes}, rest, nil
+	case len(names)+len(indexes) == 0:
+		return pathSegment{}, "", fmt.Errorf("empty \"[]\"")
+	}
+	return pathSegment{kind: unionSegment, names: names, indexes: indexes}, rest, nil
+}
+
+// closingBracket finds the "]" which closes a bracket segment,
+// ignoring brackets within quoted field names
+func closingBracket(s string) int {
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func splitUnion(s string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+func parseSlice(body string) (pathSegment, error) {
+	parts := strings.Split(body, ":")
+	if len(parts) != 2 {
+		return pathSegment{}, fmt.Errorf("invalid slice \"[%s]\"", body)
+	}
+	seg := pathSegment{kind: sliceSegment}
+	bounds := []**int{&seg.start, &seg.end}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return pathSegment{}, fmt.Errorf("invalid slice \"[%s]\"", body)
+		}
+		*bounds[i] = &n
+	}
+	return seg, nil
+}
+
+// Evaluate runs the JSONPath query against generic JSON data
+// (as produced by `encoding/json`).
+//
+// Paths which may match several values (wildcards, slices, unions,
+// recursive descent) return a list of matches. Otherwise, the single
+// matched value is returned, or nil if nothing matches.
+func (p *JSONPath) Evaluate(data interface{}) interface{} {
+	nodes := []interface{}{data}
+	definite := true
+	for _, seg := range p.segments {
+		definite = definite && seg.definite()
+		var next []interface{}
+		for _, node := range nodes {
+			if seg.recursive {
+				for _, descendant := range descendants(node) {
+					next = append(next, seg.apply(descendant)...)
+				}
+			} else {
+				next = append(next, seg.apply(node)...)
+			}
+		}
+		nodes = next
+	}
+
+	if definite {
+		if len(nodes) == 0 {
+			return nil
+		}
+		return nodes[0]
+	}
+	if nodes == nil {
+		return []interface{}{}
+	}
+	return nodes
+}
+
+// String returns the original expression
+func (p *JSONPath) String() string {
+	return p.expr
+}
+
+func (s pathSegment) apply(node interface{}) []interface{} {
+	var matches []interface{}
+	switch s.kind {
+	case fieldSegment, unionSegment:
+		if obj, ok := node.(map[string]interface{}); ok {
+			for _, name := range s.names {
+				if val, ok := obj[name]; ok {
+					matches = append(matches, val)
+				}
+			}
+		}
+		if arr, ok := node.([]interface{}); ok {
+			for _, idx := range s.indexes {
+				if val, ok := arrayIndex(arr, idx); ok {
+					matches = append(matches, val)
+				}
+			}
+		}
+	case indexSegment:
+		if arr, ok := node.([]interface{}); ok {
+			if val, ok := arrayIndex(arr, s.indexes[0]); ok {
+				matches = append(matches, val)
+			}
+		}
+	case wildcardSegment:
+		matches = append(matches, children(node)...)
+	case sliceSegment:
+		if arr, ok := node.([]interface{}); ok {
+			start, end := 0, len(arr)
+			if s.start != nil {
+				start = normalizeIndex(*s.start, len(arr))
+			}
+			if s.end != nil {
+				end = normalizeIndex(*s.end, len(arr))
+			}
+			for i := start; i < end; i++ {
+				matches = append(matches, arr[i])
+			}
+		}
+	}
+	return matches
+}
+
+func arrayIndex(arr []interface{}, idx int) (interface{}, bool) {
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx < 0 || idx >= len(arr) {
+		return nil, false
+	}
+	return arr[idx], true
+}
+
+func normalizeIndex(idx int, length int) int {
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 {
+		return 0
+	}
+	if idx > length {
+		return length
+	}
+	return idx
+}
+
+// children returns the direct children of an object or array.
+// Object fields are returned in sorted order, so results are stable
+func children(node interface{}) []interface{} {
+	switch val := node.(type) {
+	case []interface{}:
+		return val
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]interface{}, len(keys))
+		for i, k := range keys {
+			items[i] = val[k]
+		}
+		return items
+	}
+	return nil
+}
+
+// descendants returns the node, and all of its nested children
+func descendants(node interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, child := range children(node) {
+		nodes = append(nodes, descendants(child)...)
+	}
+	return nodes
+}
@@ -8,6 +8,7 @@ import (
 type ObservationContainer struct {
 	Logger
 	OutputWriter
+	Formatter
 }
 
 func New(levelLogger LevelLogger) *ObservationContainer {
@@ -19,6 +20,7 @@ func New(levelLogger LevelLogger) *ObservationContainer {
 	return &ObservationContainer{
 		Logger:       logger,
 		OutputWriter: os.Stdout,
+		Formatter:    &JSONFormatter{},
 	}
 }
 
@@ -2,6 +2,9 @@ package util
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"io/ioutil"
 	"time"
 
@@ -18,11 +21,12 @@ import (
 )
 
 type NewAPIClientInput struct {
-	credentials *credentials.Credentials
-	region      *string
-	host        *string
-	basePath    *string
-	token       *string
+	credentials    *credentials.Credentials
+	region         *string
+	host           *string
+	basePath       *string
+	retry          RetryPolicy
+	reauthenticate func() error
 }
 
 func NewAPIClient(input *NewAPIClientInput) *operations.Client {
@@ -40,6 +44,9 @@ func NewAPIClient(input *NewAPIClientInput) *operations.Client {
 		Creds:   input.credentials,
 		Region:  region,
 		Logger:  log,
+		Retry:   input.retry,
+
+		Reauthenticate: input.reauthenticate,
 	}
 	sig4HTTTPClient := http.Client{Transport: &sig4RoundTripper}
 	httpTransport := httptransport.NewWithClient(
@@ -48,7 +55,7 @@ func NewAPIClient(input *NewAPIClientInput) *operations.Client {
 		nil,
 		&sig4HTTTPClient,
 	)
-	client := apiclient.New(httpTransport, strfmt.Default)
+	client := apiclient.New(&ErrorResponseTransport{Transport: httpTransport}, strfmt.Default)
 	return client.Operations.(*operations.Client)
 }
 
@@ -58,6 +65,13 @@ type Sig4RoundTripper struct {
 	Creds   *credentials.Credentials
 	Region  string
 	Logger  observation.Logger
+	// Retry configures how failed requests are retried.
+	// Requests are not retried if MaxAttempts is unset.
+	Retry RetryPolicy
+	// Reauthenticate is called when the API rejects our credentials as expired,
+	// before retrying the request once with refreshed credentials.
+	// If nil, or if it fails, the API error is returned.
+	Reauthenticate func() error
 }
 
 func (srt Sig4RoundTripper) RoundTrip(req *http.Request) (res *http.Response, e error) {
@@ -68,34 +82,176 @@ func (srt Sig4RoundTripper) RoundTrip(req *http.Request) (res *http.Response, e
 	}
 	log.Debugln("V4 Signing Request:\n", string(dumpedReq))
 
+	// Read the body up front, so it can be re-sent on each attempt
+	var body []byte
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			log.Fatalln("Error reading payload for v4 signing. ", err)
+		}
+	}
+
+	start := time.Now()
+	res, e = srt.roundTripWithRetry(req, body)
+
+	// If our credentials expired during the command,
+	// login again and retry once
+	if e == nil && isExpiredCredentialsResponse(res) && srt.Reauthenticate != nil {
+		log.Warnln("DCE API credentials have expired")
+		if err := srt.reauthenticate(req.Context()); err != nil {
+			log.Warnf("Unable to re-authenticate: %s", err)
+		} else {
+			res.Body.Close()
+			srt.Creds.Expire()
+
+			// Logging in may take longer than the request timeout,
+			// so give the retry the full timeout again
+			ctx, cancel := restartDeadline(req.Context(), start)
+			res, e = srt.roundTripWithRetry(req.WithContext(ctx), body)
+			if e != nil {
+				cancel()
+			} else {
+				// The response body is read after RoundTrip returns,
+				// so the context is cancelled once it is closed
+				res.Body = cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+			}
+		}
+	}
+
+	return res, e
+}
+
+// reauthenticate logs in again, unless the request
+// was made with WithoutReauthentication
+func (srt Sig4RoundTripper) reauthenticate(ctx context.Context) error {
+	if disabled, _ := ctx.Value(noReauthenticationKey{}).(bool); disabled {
+		return errors.New("not logging in again for a background request. Run `dce auth` to log in again")
+	}
+	return srt.Reauthenticate()
+}
+
+type noReauthenticationKey struct{}
+
+// WithoutReauthentication returns a context for API requests which should fail,
+// rather than log in again, if the API credentials have expired.
+// Used for requests made in the background, which must not prompt the user.
+func WithoutReauthentication(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noReauthenticationKey{}, true)
+}
+
+// restartDeadline returns a copy of the context, with a deadline
+// as far from now as the original deadline was from start.
+// The copy is still cancelled if the original context is cancelled (eg. on SIGINT).
+func restartDeadline(ctx context.Context, start time.Time) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	restarted, cancel := context.WithTimeout(detachedContext{ctx}, deadline.Sub(start))
+	go func() {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				cancel()
+			}
+		case <-restarted.Done():
+		}
+	}()
+	return restarted, cancel
+}
+
+// cancelOnClose cancels a request's context when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// detachedContext keeps the values of a context,
+// but not its deadline or cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (c detachedContext) Done() <-chan struct{}             { return nil }
+func (c detachedContext) Err() error                        { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// roundTripWithRetry sends the request, retrying according to the RetryPolicy
+func (srt Sig4RoundTripper) roundTripWithRetry(req *http.Request, body []byte) (res *http.Response, e error) {
+	log := srt.Logger
+	for attempt := 1; ; attempt++ {
+		res, e = srt.roundTripSigned(req, body)
+
+		if attempt >= srt.Retry.MaxAttempts {
+			break
+		}
+		var delay time.Duration
+		if e != nil {
+			if !isRetryableError(req.Method, e) {
+				break
+			}
+			delay = srt.Retry.Backoff(attempt)
+			log.Debugf("Request failed: %s. Retrying in %s (attempt %d of %d)", e, delay, attempt+1, srt.Retry.MaxAttempts)
+		} else {
+			if !isRetryableResponse(req.Method, res) {
+				break
+			}
+			var ok bool
+			if delay, ok = srt.Retry.retryAfter(res); !ok {
+				delay = srt.Retry.Backoff(attempt)
+			}
+			log.Debugf("Request failed with status %d. Retrying in %s (attempt %d of %d)", res.StatusCode, delay, attempt+1, srt.Retry.MaxAttempts)
+		}
+		if !sleepContext(req.Context(), delay) {
+			break
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+	}
+
+	return res, e
+}
+
+// roundTripSigned signs and sends a copy of the request.
+// Requests are re-signed on each attempt, so that retries
+// are not rejected for having an expired signature.
+func (srt Sig4RoundTripper) roundTripSigned(req *http.Request, body []byte) (*http.Response, error) {
+	log := srt.Logger
+	req = req.Clone(req.Context())
+
 	signer := sigv4.NewSigner(srt.Creds)
 	now := time.Now().Add(time.Duration(30) * time.Second)
 
 	// If there's a json provided, add it when signing
 	// Body does not matter if added before the signing, it will be overwritten
 
+	// Signing fails if the API credentials have expired, which may happen
+	// in a background request, eg. refreshing `dce leases shell` credentials
 	executeAPI := "execute-api"
-	if req.Body != nil {
-		body, err := ioutil.ReadAll(req.Body)
-		if err != nil {
-			log.Fatalln("Error reading payload for v4 signing. ", err)
-		}
-
+	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
-		_, err = signer.Sign(req, bytes.NewReader(body), executeAPI, srt.Region, now)
+		_, err := signer.Sign(req, bytes.NewReader(body), executeAPI, srt.Region, now)
 		if err != nil {
-			log.Fatalln("Error v4 signing payload. ", err)
+			return nil, &SigningError{Err: err}
 		}
 	} else {
 		_, err := signer.Sign(req, nil,
 			executeAPI, srt.Region, now)
 		if err != nil {
-			log.Fatalln("Error while v4 signing request. ", err)
+			return nil, &SigningError{Err: err}
 		}
 	}
 
-	res, e = srt.Proxied.RoundTrip(req)
+	res, err := srt.Proxied.RoundTrip(req)
 
 	log.Debugln("Response: ", res)
-	return res, e
+	return res, err
 }
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+)
+
+// ErrorResponseTransport returns a *runtime.APIError for every DCE API response
+// with a 4xx or 5xx status code, including the response body.
+//
+// The generated API client discards the response body for error responses,
+// so the body is read before the client sees it, and decoded by the service.
+type ErrorResponseTransport struct {
+	Transport runtime.ClientTransport
+}
+
+// Submit sends the operation, using the wrapped transport
+func (t *ErrorResponseTransport) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	wrapped := *op
+	wrapped.Reader = errorResponseReader{opName: op.ID, reader: op.Reader}
+	return t.Transport.Submit(&wrapped)
+}
+
+type errorResponseReader struct {
+	opName string
+	reader runtime.ClientResponseReader
+}
+
+func (r errorResponseReader) ReadResponse(res runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if res.Code() < 400 {
+		return r.reader.ReadResponse(res, consumer)
+	}
+	errRes := &errorResponse{ClientResponse: res}
+	if body := res.Body(); body != nil {
+		errRes.body, _ = ioutil.ReadAll(body)
+	}
+	return nil, runtime.NewAPIError(r.opName, errRes, res.Code())
+}
+
+// errorResponse is an error response, with its body read into memory,
+// so it can be read after the API client has closed the response
+type errorResponse struct {
+	runtime.ClientResponse
+	body []byte
+}
+
+func (r *errorResponse) Body() io.ReadCloser {
+	return ioutil.NopCloser(bytes.NewReader(r.body))
+}
+
+func (r *errorResponse) String() string {
+	return string(r.body)
+}
+
+// SigningError is returned when a request can't be signed
+// with the DCE API credentials, eg. because they have expired
+type SigningError struct {
+	Err error
+}
+
+func (e *SigningError) Error() string {
+	return fmt.Sprintf("failed to sign request: %s", e.Err)
+}
+
+func (e *SigningError) Unwrap() error {
+	return e.Err
+}
+
+// gatewayErrorBody is the error response body returned by API Gateway,
+// eg. `{"message": "The security token included in the request is expired"}`
+type gatewayErrorBody struct {
+	Message string `json:"message"`
+}
+
+// API Gateway error types for requests signed with expired or invalid credentials.
+// See https://docs.aws.amazon.com/apigateway/api-reference/handling-errors/
+var expiredCredentialsErrorTypes = []string{"ExpiredTokenException", "InvalidSignatureException"}
+
+// Messages returned by API Gateway with expired or invalid credentials,
+// in case the x-amzn-ErrorType header is missing
+var expiredCredentialsMessages = []string{
+	"security token included in the request is expired",
+	"Signature expired",
+	"request signature we calculated does not match",
+}
+
+// isExpiredCredentialsResponse checks if the API rejected the request
+// because it was signed with expired credentials.
+// The response body is preserved, so it can be read again.
+func isExpiredCredentialsResponse(res *http.Response) bool {
+	if res.StatusCode != http.StatusForbidden {
+		return false
+	}
+	errorType := res.Header.Get("x-amzn-ErrorType")
+	for _, expiredType := range expiredCredentialsErrorTypes {
+		if strings.HasPrefix(errorType, expiredType) {
+			return true
+		}
+	}
+	if res.Body == nil {
+		return false
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	var errBody gatewayErrorBody
+	if err := json.Unmarshal(body, &errBody); err != nil {
+		return false
+	}
+	for _, msg := range expiredCredentialsMessages {
+		if strings.Contains(errBody.Message, msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,12 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Optum/dce-cli/configs"
 	observ "github.com/Optum/dce-cli/internal/observation"
@@ -13,6 +14,8 @@ import (
 	awsSession "github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/mitchellh/go-homedir"
 )
 
 type AWSUtil struct {
@@ -117,29 +120,120 @@ func (u *AWSUtil) UpdateLambdasFromS3Assets(lambdaNames []string, bucket string,
 	log.Infoln("Finished updating AWS Lambda functions.")
 }
 
-// ConfigureAWSCLICredentials sets credential values in the credentials config file used by the aws cli
-// Care should be taken to mitigate CWE-78 (https://cwe.mitre.org/data/definitions/78.html)
-// by ensuring inputs come from a trusted source.
-func (u *AWSUtil) ConfigureAWSCLICredentials(accessKeyID, secretAccessKey, sessionToken, profile string) {
-	/*
-		#nosec CWE-78: this value is populated by response data sent from the DCE backend, which is a trusted source.
-	*/
-	_, err := exec.Command("aws", "configure", "--profile", profile, "set", "aws_access_key_id", accessKeyID).CombinedOutput()
+// AWSCLICredentials are credentials to write to an AWS CLI profile
+type AWSCLICredentials struct {
+	Profile         string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Expiration of the credentials, if known
+	Expiration time.Time
+	// Region is written to the profile in the AWS CLI config file
+	Region string
+	// LeaseID is the DCE lease the credentials belong to, if known
+	LeaseID string
+}
+
+// Metadata keys written to the AWS CLI credentials file.
+// The AWS CLI and SDKs ignore keys they don't recognize.
+const (
+	awsCredentialsExpirationKey = "expiration"
+	awsCredentialsLeaseIDKey    = "dce_lease_id"
+)
+
+// ConfigureAWSCLICredentials writes credentials to a profile in the credentials file used by the aws cli,
+// and sets the region for the profile in the aws cli config file.
+// Other profiles, and comments, are preserved.
+func (u *AWSUtil) ConfigureAWSCLICredentials(creds *AWSCLICredentials) error {
+	expiration := ""
+	if !creds.Expiration.IsZero() {
+		expiration = creds.Expiration.UTC().Format(time.RFC3339)
+	}
+	// Empty values remove stale metadata left by previous logins
+	err := setINIValues(awsCredentialsFile(), creds.Profile, map[string]string{
+		"aws_access_key_id":         creds.AccessKeyID,
+		"aws_secret_access_key":     creds.SecretAccessKey,
+		"aws_session_token":         creds.SessionToken,
+		awsCredentialsExpirationKey: expiration,
+		awsCredentialsLeaseIDKey:    creds.LeaseID,
+	})
 	if err != nil {
-		log.Fatalln(err)
+		return err
+	}
+
+	if creds.Region == "" {
+		return nil
+	}
+	return u.SetAWSConfigProfile(creds.Profile, map[string]string{
+		"region": creds.Region,
+	})
+}
+
+// awsCredentialsKeys and awsConfigKeys are the keys which dce writes to AWS CLI profiles
+var (
+	awsCredentialsKeys = []string{
+		"aws_access_key_id",
+		"aws_secret_access_key",
+		"aws_session_token",
+		awsCredentialsExpirationKey,
+		awsCredentialsLeaseIDKey,
+	}
+	awsConfigKeys = []string{"region", "credential_process"}
+)
+
+// RemoveAWSCLIProfile removes the keys written by dce from a profile in the
+// credentials file and the config file used by the aws cli. Other keys
+// in the profile, such as those added by the user, are kept, and the
+// profile is only removed if it has no other keys.
+func (u *AWSUtil) RemoveAWSCLIProfile(profile string) error {
+	if _, err := removeINIKeys(awsCredentialsFile(), profile, awsCredentialsKeys); err != nil {
+		return err
+	}
+	_, err := removeINIKeys(awsConfigFile(), awsConfigSection(profile), awsConfigKeys)
+	return err
+}
+
+// SetAWSConfigProfile sets values for a profile in the config file used by the aws cli.
+// Other profiles, and other values in the profile, are preserved.
+func (u *AWSUtil) SetAWSConfigProfile(profile string, settings map[string]string) error {
+	return setINIValues(awsConfigFile(), awsConfigSection(profile), settings)
+}
+
+// awsConfigSection returns the section name of a profile in the aws cli config file
+func awsConfigSection(profile string) string {
+	if profile == "default" {
+		return profile
+	}
+	return "profile " + profile
+}
+
+// GetCallerIdentity returns the identity of the AWS credentials used by DCE
+func (u *AWSUtil) GetCallerIdentity(ctx context.Context) (*sts.GetCallerIdentityOutput, error) {
+	return sts.New(u.Session).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+}
+
+// awsCredentialsFile returns the location of the credentials file used by the aws cli,
+// which may be overridden with the AWS_SHARED_CREDENTIALS_FILE env var
+func awsCredentialsFile() string {
+	if file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); file != "" {
+		return file
 	}
-	/*
-		#nosec CWE-78: this value is populated by response data sent from the DCE backend, which is a trusted source.
-	*/
-	_, err = exec.Command("aws", "configure", "--profile", profile, "set", "aws_secret_access_key", secretAccessKey).CombinedOutput()
+	homeDir, err := homedir.Dir()
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatalf("error: %v", err)
+	}
+	return filepath.Join(homeDir, ".aws", "credentials")
+}
+
+// awsConfigFile returns the location of the config file used by the aws cli,
+// which may be overridden with the AWS_CONFIG_FILE env var
+func awsConfigFile() string {
+	if file := os.Getenv("AWS_CONFIG_FILE"); file != "" {
+		return file
 	}
-	/*
-		#nosec CWE-78: this value is populated by response data sent from the DCE backend, which is a trusted source.
-	*/
-	_, err = exec.Command("aws", "configure", "--profile", profile, "set", "aws_session_token", sessionToken).CombinedOutput()
+	homeDir, err := homedir.Dir()
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatalf("error: %v", err)
 	}
+	return filepath.Join(homeDir, ".aws", "config")
 }
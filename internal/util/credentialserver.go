@@ -0,0 +1,121 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ContainerCredentials are AWS credentials, in the format
+// AWS SDKs expect from a container credentials endpoint
+type ContainerCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      time.Time
+}
+
+// CredentialServer serves AWS credentials on a random localhost port,
+// in the format of the ECS container credentials endpoint. AWS SDKs fetch
+// credentials from it when AWS_CONTAINER_CREDENTIALS_FULL_URI is set to URL,
+// and AWS_CONTAINER_AUTHORIZATION_TOKEN is set to AuthToken.
+//
+// Credentials are refreshed when they are about to expire, so
+// long running processes always receive valid credentials.
+type CredentialServer struct {
+	URL       string
+	AuthToken string
+
+	refresh       func() (*ContainerCredentials, error)
+	refreshWindow time.Duration
+	mu            sync.Mutex
+	creds         *ContainerCredentials
+	server        *http.Server
+}
+
+// StartCredentialServer serves creds until Close is called.
+// `refresh` is called for new credentials when creds expire
+// within the refresh window. If it fails, the current credentials
+// are served until they expire.
+func StartCredentialServer(creds *ContainerCredentials, refreshWindow time.Duration, refresh func() (*ContainerCredentials, error)) (*CredentialServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start localhost server: %s", err)
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to generate credentials server token: %s", err)
+	}
+
+	s := &CredentialServer{
+		URL:           (&url.URL{Scheme: "http", Host: listener.Addr().String(), Path: "/credentials"}).String(),
+		AuthToken:     hex.EncodeToString(token),
+		refresh:       refresh,
+		refreshWindow: refreshWindow,
+		creds:         creds,
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/credentials", s)
+	s.server = &http.Server{Handler: mux}
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// ServeHTTP returns the credentials to requests with the auth token
+func (s *CredentialServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(s.AuthToken)) != 1 {
+		writeCredentialsError(w, http.StatusUnauthorized, "Unauthorized", "invalid authorization token")
+		return
+	}
+
+	creds, err := s.credentials()
+	if err != nil {
+		writeCredentialsError(w, http.StatusInternalServerError, "RefreshFailed", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(creds)
+}
+
+// credentials returns the current credentials,
+// refreshing them if they are about to expire
+func (s *CredentialServer) credentials() (*ContainerCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Now().Add(s.refreshWindow).Before(s.creds.Expiration) {
+		return s.creds, nil
+	}
+	creds, err := s.refresh()
+	if err != nil {
+		// Keep serving the current credentials until they expire
+		if time.Now().Before(s.creds.Expiration) {
+			return s.creds, nil
+		}
+		return nil, fmt.Errorf("failed to refresh credentials: %s", err)
+	}
+	s.creds = creds
+	return creds, nil
+}
+
+// Close stops the server
+func (s *CredentialServer) Close() error {
+	return s.server.Close()
+}
+
+func writeCredentialsError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
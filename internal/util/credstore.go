@@ -0,0 +1,408 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Optum/dce-cli/configs"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// CredentialStorePlaintext stores the API token in the DCE config file
+	CredentialStorePlaintext = configs.CredentialStorePlaintext
+	// CredentialStoreFile stores the API token in a file,
+	// encrypted with a key derived from a passphrase
+	CredentialStoreFile = configs.CredentialStoreFile
+	// CredentialStoreHelper stores the API token using a credential helper executable,
+	// which implements the docker credential helper protocol
+	CredentialStoreHelper = configs.CredentialStoreHelper
+)
+
+// CredentialStores are the supported values of the `api.credentialStore.type` config
+var CredentialStores = configs.CredentialStoreTypes
+
+// TokenPassphraseEnv may be set to provide the passphrase for the encrypted token file,
+// rather than prompting for it
+const TokenPassphraseEnv = "DCE_TOKEN_PASSPHRASE"
+
+// CredentialStore stores the DCE API token
+type CredentialStore interface {
+	// GetToken returns the stored API token, or nil if none is stored
+	GetToken() (*string, error)
+	StoreToken(token string) error
+	EraseToken() error
+	// TokenLocation describes where the API token is stored, for display to the user
+	TokenLocation() string
+}
+
+// NewCredentialStore returns the credential store configured by `api.credentialStore`
+func NewCredentialStore(config *configs.Root, fs FileSystemer, passphrase func() (string, error)) (CredentialStore, error) {
+	storeConfig := config.API.CredentialStore
+	storeType := CredentialStorePlaintext
+	if storeConfig.Type != nil && *storeConfig.Type != "" {
+		storeType = *storeConfig.Type
+	}
+
+	// A token set by the DCE_API_TOKEN env var or --token flag
+	// is used instead of the stored token
+	if storeType != CredentialStorePlaintext && config.IsOverridden("api.token") {
+		return &StaticCredentialStore{Token: *config.API.Token}, nil
+	}
+
+	switch storeType {
+	case CredentialStorePlaintext:
+		return &PlaintextCredentialStore{Config: config, FileSystem: fs}, nil
+	case CredentialStoreFile:
+		// Each context has its own token
+		fileName := "token.enc"
+		if config.ActiveContext() != configs.DefaultContext {
+			fileName = "token-" + config.ActiveContext() + ".enc"
+		}
+		path := filepath.Join(fs.GetConfigDir(), fileName)
+		if storeConfig.File != nil && *storeConfig.File != "" {
+			path = *storeConfig.File
+		}
+		return &plaintextMigratingStore{
+			CredentialStore: &EncryptedFileCredentialStore{Path: path, Passphrase: passphrase},
+			Config:          config,
+			FileSystem:      fs,
+		}, nil
+	case CredentialStoreHelper:
+		if storeConfig.Helper == nil || *storeConfig.Helper == "" {
+			return nil, errors.New("api.credentialStore.helper must be set, when using the \"helper\" credential store")
+		}
+		return &plaintextMigratingStore{
+			CredentialStore: &HelperCredentialStore{Helper: *storeConfig.Helper, ServerURL: credentialServerURL(config)},
+			Config:          config,
+			FileSystem:      fs,
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid api.credentialStore.type \"%s\": must be one of %v", storeType, CredentialStores)
+	}
+}
+
+// credentialServerURL identifies the DCE API token in a credential helper
+func credentialServerURL(config *configs.Root) string {
+	if config.API.Host == nil {
+		return "dce"
+	}
+	basePath := ""
+	if config.API.BasePath != nil {
+		basePath = *config.API.BasePath
+	}
+	return "https://" + *config.API.Host + basePath
+}
+
+// PlaintextCredentialStore stores the API token in the DCE config file
+type PlaintextCredentialStore struct {
+	Config     *configs.Root
+	FileSystem FileSystemer
+}
+
+func (s *PlaintextCredentialStore) GetToken() (*string, error) {
+	return s.Config.API.Token, nil
+}
+
+func (s *PlaintextCredentialStore) StoreToken(token string) error {
+	s.Config.API.Token = &token
+	return s.FileSystem.WriteConfig()
+}
+
+func (s *PlaintextCredentialStore) EraseToken() error {
+	s.Config.API.Token = nil
+	return s.FileSystem.WriteConfig()
+}
+
+func (s *PlaintextCredentialStore) TokenLocation() string {
+	return s.FileSystem.GetConfigFile()
+}
+
+// plaintextMigratingStore removes the API token from the DCE config file,
+// when another credential store is configured. A token left in the config file
+// by the plaintext store is moved to the configured store the first time it's read.
+type plaintextMigratingStore struct {
+	CredentialStore
+	Config     *configs.Root
+	FileSystem FileSystemer
+}
+
+func (s *plaintextMigratingStore) GetToken() (*string, error) {
+	token, err := s.CredentialStore.GetToken()
+	if err != nil {
+		return nil, err
+	}
+	plaintext := s.Config.API.Token
+	if plaintext == nil || *plaintext == "" {
+		return token, nil
+	}
+	if token == nil {
+		if err := s.CredentialStore.StoreToken(*plaintext); err != nil {
+			return nil, fmt.Errorf("failed to move the API token from %s to %s: %s",
+				s.FileSystem.GetConfigFile(), s.CredentialStore.TokenLocation(), err)
+		}
+		token = plaintext
+	}
+	return token, s.removePlaintextToken()
+}
+
+func (s *plaintextMigratingStore) StoreToken(token string) error {
+	if err := s.CredentialStore.StoreToken(token); err != nil {
+		return err
+	}
+	return s.removePlaintextToken()
+}
+
+func (s *plaintextMigratingStore) EraseToken() error {
+	if err := s.CredentialStore.EraseToken(); err != nil {
+		return err
+	}
+	return s.removePlaintextToken()
+}
+
+// removePlaintextToken removes the API token from the DCE config file
+func (s *plaintextMigratingStore) removePlaintextToken() error {
+	if s.Config.API.Token == nil {
+		return nil
+	}
+	s.Config.API.Token = nil
+	return s.FileSystem.WriteConfig()
+}
+
+// StaticCredentialStore is a read-only store for a single API token,
+// eg. to validate a token before it is saved
+type StaticCredentialStore struct {
+	Token string
+}
+
+func (s *StaticCredentialStore) GetToken() (*string, error) {
+	return &s.Token, nil
+}
+
+func (s *StaticCredentialStore) StoreToken(token string) error {
+	return errors.New("cannot store API token in a static credential store")
+}
+
+func (s *StaticCredentialStore) EraseToken() error {
+	return errors.New("cannot erase API token from a static credential store")
+}
+
+func (s *StaticCredentialStore) TokenLocation() string {
+	return "memory"
+}
+
+// EncryptedFileCredentialStore stores the API token in a file,
+// encrypted with AES-GCM, using a key derived from a passphrase with scrypt
+type EncryptedFileCredentialStore struct {
+	Path string
+	// Passphrase returns the passphrase used to encrypt the file.
+	// It is called once per process, unless the passphrase is incorrect.
+	Passphrase func() (string, error)
+	passphrase *string
+}
+
+// encryptedToken is the format of the encrypted token file
+type encryptedToken struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+func (s *EncryptedFileCredentialStore) GetToken() (*string, error) {
+	// #nosec
+	contents, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var encrypted encryptedToken
+	if err := json.Unmarshal(contents, &encrypted); err != nil {
+		return nil, fmt.Errorf("invalid encrypted token file %s: %s", s.Path, err)
+	}
+	gcm, err := s.cipher(encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		// Forget the incorrect passphrase, so it's asked for again next time
+		s.passphrase = nil
+		return nil, fmt.Errorf("failed to decrypt %s: incorrect passphrase", s.Path)
+	}
+	token := string(plaintext)
+	return &token, nil
+}
+
+func (s *EncryptedFileCredentialStore) StoreToken(token string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(encryptedToken{
+		Version:    1,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(token), nil),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, contents, 0600)
+}
+
+func (s *EncryptedFileCredentialStore) EraseToken() error {
+	err := os.Remove(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *EncryptedFileCredentialStore) TokenLocation() string {
+	return s.Path
+}
+
+// cipher derives the encryption key from the passphrase and salt
+func (s *EncryptedFileCredentialStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.passphrase == nil {
+		passphrase, err := s.Passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == "" {
+			return nil, errors.New("a passphrase is required to encrypt the API token")
+		}
+		s.passphrase = &passphrase
+	}
+
+	key, err := scrypt.Key([]byte(*s.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// HelperCredentialStore stores the API token using an external credential helper executable,
+// which implements the docker credential helper protocol (https://github.com/docker/docker-credential-helpers).
+// This allows storing the token in the OS keychain, eg. with `docker-credential-osxkeychain`.
+type HelperCredentialStore struct {
+	// Helper is the name or path of the credential helper executable
+	Helper string
+	// ServerURL identifies the DCE API token in the credential helper
+	ServerURL string
+}
+
+// helperCredentials is the JSON format used by the credential helper protocol
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// Credential helpers print this message when no credentials are stored
+const helperNotFoundMessage = "credentials not found in native keychain"
+
+func (s *HelperCredentialStore) GetToken() (*string, error) {
+	out, err := s.run("get", s.ServerURL)
+	if err != nil {
+		if strings.Contains(out, helperNotFoundMessage) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var creds helperCredentials
+	if err := json.Unmarshal([]byte(out), &creds); err != nil {
+		return nil, fmt.Errorf("invalid response from credential helper %s: %s", s.Helper, err)
+	}
+	return &creds.Secret, nil
+}
+
+func (s *HelperCredentialStore) StoreToken(token string) error {
+	input, err := json.Marshal(helperCredentials{
+		ServerURL: s.ServerURL,
+		Username:  "dce",
+		Secret:    token,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.run("store", string(input))
+	return err
+}
+
+func (s *HelperCredentialStore) EraseToken() error {
+	out, err := s.run("erase", s.ServerURL)
+	if err != nil && strings.Contains(out, helperNotFoundMessage) {
+		return nil
+	}
+	return err
+}
+
+func (s *HelperCredentialStore) TokenLocation() string {
+	return "credential helper " + s.Helper
+}
+
+// run calls the credential helper with the given action and stdin,
+// and returns its stdout
+// Care should be taken to mitigate CWE-78 (https://cwe.mitre.org/data/definitions/78.html)
+// by ensuring the helper is configured by the user.
+func (s *HelperCredentialStore) run(action string, input string) (string, error) {
+	/*
+		#nosec CWE-78: the helper is configured by the user
+	*/
+	cmd := exec.Command(s.Helper, action)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	out := strings.TrimSpace(stdout.String())
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = out
+		}
+		return out, fmt.Errorf("credential helper %s %s failed: %s: %s", s.Helper, action, err, msg)
+	}
+	return out, nil
+}
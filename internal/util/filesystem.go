@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -16,41 +17,89 @@ type FileSystemUtil struct {
 	Config     *configs.Root
 	ConfigFile string
 	ConfigDir  string
+	// loaded is the config as it was read from the config file.
+	// If nil, WriteConfig overwrites the whole config file.
+	loaded *configs.Root
 }
 
-func (u *FileSystemUtil) writeToYAMLFile(path string, _struct interface{}) error {
-	var deferredErr error = nil
-	_yaml, err := yaml.Marshal(_struct)
-	if err != nil {
+// WriteConfig saves the Config object to the config file.
+// Settings are saved to the active context.
+//
+// Only the settings which have changed since the config file was read are
+// written, merged with the config file as it is now, so that changes made
+// by other dce processes at the same time are kept.
+func (u *FileSystemUtil) WriteConfig() error {
+	if err := os.MkdirAll(filepath.Dir(u.ConfigFile), 0700); err != nil {
 		return err
 	}
+	unlock, err := u.lockConfig()
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %s", u.ConfigFile, err)
+	}
+	defer unlock()
 
-	if !u.IsExistingFile(path) {
-		err := os.MkdirAll(u.GetConfigDir(), os.FileMode(0700))
-		if err != nil {
+	config := u.Config.ForFile()
+	if u.loaded != nil {
+		onDisk := &configs.Root{}
+		data, err := ioutil.ReadFile(u.ConfigFile)
+		if err != nil && !os.IsNotExist(err) {
 			return err
 		}
-		var file *os.File
-		file, err = os.Create(path)
-		if err != nil {
+		if err := yaml.Unmarshal(data, onDisk); err != nil {
 			return err
 		}
-		// #nosec
-		defer file.Close()
+		onDisk.MergeChanges(u.loaded, config)
+		config = onDisk
 	}
+	// Config files are always written with the current layout
+	config.Version = configs.CurrentVersion
 
-	// #nosec
-	err = ioutil.WriteFile(path, _yaml, 0644)
+	data, err := yaml.Marshal(config)
 	if err != nil {
 		return err
 	}
-	return deferredErr
+	// The config may contain the API token, so only the user may read it
+	if err := writeFileAtomic(u.ConfigFile, data, 0600); err != nil {
+		return err
+	}
+	u.snapshotConfig()
+	return nil
 }
 
-// WriteConfig writes the Config objects as YAML
-// to the config file location
-func (u *FileSystemUtil) WriteConfig() error {
-	return u.writeToYAMLFile(u.ConfigFile, u.Config)
+// lockConfig takes an advisory lock on the config file, so that only one
+// dce process writes to it at a time. Returns a func to release the lock.
+func (u *FileSystemUtil) lockConfig() (func(), error) {
+	// The config file is replaced on each write, so a separate lock file is used
+	lock, err := os.OpenFile(u.ConfigFile+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		_ = lock.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(lock)
+		_ = lock.Close()
+	}, nil
+}
+
+// snapshotConfig records the settings in the config file,
+// so that WriteConfig can tell which settings have been changed
+func (u *FileSystemUtil) snapshotConfig() {
+	u.loaded = &configs.Root{}
+	if !u.IsExistingFile(u.ConfigFile) {
+		return
+	}
+	// Copy the config, so that changes to it don't change the snapshot
+	data, err := yaml.Marshal(u.Config.ForFile())
+	if err == nil {
+		err = yaml.Unmarshal(data, u.loaded)
+	}
+	if err != nil {
+		// Write the whole config
+		u.loaded = nil
+	}
 }
 
 // ReadInConfig loads the configuration from the configuration file
@@ -61,7 +110,11 @@ func (u *FileSystemUtil) ReadInConfig() error {
 		return err
 	}
 
-	return yaml.Unmarshal(yamlStr, u.Config)
+	if err := yaml.Unmarshal(yamlStr, u.Config); err != nil {
+		return err
+	}
+	u.snapshotConfig()
+	return nil
 }
 
 func (u *FileSystemUtil) GetConfigFile() string {
@@ -0,0 +1,214 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matches INI section headers, eg. `[default]` or `[profile my-profile]`
+var iniSectionRegex = regexp.MustCompile(`^\s*\[\s*([^\]]*?)\s*\]`)
+
+// removeINIKeys removes keys from a section of an INI file, such as the
+// AWS CLI credentials file. The section is removed too, if nothing else is
+// left in it. Other keys, sections and comments are preserved.
+// Returns false if nothing was removed.
+func removeINIKeys(path string, section string, keys []string) (bool, error) {
+	// #nosec
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	remove := map[string]bool{}
+	for _, key := range keys {
+		remove[key] = true
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	var out []string
+	removed := false
+	for i := 0; i < len(lines); {
+		match := iniSectionRegex.FindStringSubmatch(lines[i])
+		if match == nil || match[1] != section {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !iniSectionRegex.MatchString(lines[end]) {
+			end++
+		}
+		var kept []string
+		isEmpty := true
+		for _, line := range lines[i+1 : end] {
+			if key := iniKeyRegex.FindStringSubmatch(line); key != nil && remove[key[1]] {
+				removed = true
+				continue
+			}
+			if strings.TrimSpace(line) != "" {
+				isEmpty = false
+			}
+			kept = append(kept, line)
+		}
+		if isEmpty {
+			// Drop the section, along with the blank lines after it
+			removed = true
+		} else {
+			out = append(append(out, lines[i]), kept...)
+		}
+		i = end
+	}
+	if !removed {
+		return false, nil
+	}
+
+	result := strings.Join(out, "\n")
+	if strings.HasSuffix(string(contents), "\n") && result != "" {
+		result += "\n"
+	}
+	return true, writeFileAtomic(path, []byte(result), info.Mode())
+}
+
+// Matches INI key/value lines, eg. `aws_access_key_id = AKIA...`
+var iniKeyRegex = regexp.MustCompile(`^\s*([^=#;\[\s]+)\s*=`)
+
+// setINIValues sets keys in a section of an INI file, such as the AWS CLI config file.
+// Existing keys are updated in place, and new keys are added to the end of the section.
+// Keys with an empty value are removed.
+// The section and file are created if they do not exist.
+// Other keys, sections and comments are preserved.
+func setINIValues(path string, section string, values map[string]string) error {
+	mode := os.FileMode(0600)
+	// #nosec
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		mode = info.Mode()
+	} else if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	var lines []string
+	if len(contents) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	}
+
+	// Find the section, and update any existing keys
+	start, end := -1, len(lines)
+	written := map[string]bool{}
+	removed := map[int]bool{}
+	for i, line := range lines {
+		if match := iniSectionRegex.FindStringSubmatch(line); match != nil {
+			if start >= 0 {
+				end = i
+				break
+			}
+			if match[1] == section {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		if match := iniKeyRegex.FindStringSubmatch(line); match != nil {
+			if val, ok := values[match[1]]; ok {
+				lines[i] = match[1] + " = " + val
+				written[match[1]] = true
+				if val == "" {
+					removed[i] = true
+				}
+			}
+		}
+	}
+
+	// Remove keys with empty values
+	if len(removed) > 0 {
+		var kept []string
+		for i, line := range lines {
+			if !removed[i] {
+				kept = append(kept, line)
+			} else if i < end {
+				end--
+			}
+		}
+		lines = kept
+	}
+
+	// Add new keys in a consistent order
+	var keys []string
+	for key, val := range values {
+		if !written[key] && val != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var added []string
+	for _, key := range keys {
+		added = append(added, key+" = "+values[key])
+	}
+
+	if start < 0 {
+		if len(added) == 0 {
+			return nil
+		}
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]")
+		lines = append(lines, added...)
+	} else {
+		// Insert after the last key in the section,
+		// so blank lines between sections are kept
+		insertAt := end
+		for insertAt > start+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+			insertAt--
+		}
+		lines = append(lines[:insertAt], append(added, lines[insertAt:]...)...)
+	}
+
+	return writeFileAtomic(path, []byte(strings.Join(lines, "\n")+"\n"), mode)
+}
+
+// writeFileAtomic writes to a temp file, and renames it over the destination file,
+// so that readers never see a partially written file
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	// Cleanup the temp file, if we fail to rename it
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpFile.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
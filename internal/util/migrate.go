@@ -0,0 +1,85 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Optum/dce-cli/configs"
+)
+
+// LegacyConfigFile is where dce v0.3.0 and earlier kept the config file
+func LegacyConfigFile(homeDir string) string {
+	return filepath.Join(homeDir, ".dce.yaml")
+}
+
+// MigrateConfig upgrades the config file to configs.CurrentVersion.
+// If the config file does not exist, it is moved from the first of the
+// legacyFiles which exists. The original file is kept as a backup.
+// Returns descriptions of the changes made, or none if the config file is up to date.
+func (u *FileSystemUtil) MigrateConfig(legacyFiles []string) ([]string, error) {
+	if u.migrationSource(legacyFiles) == "" {
+		return nil, nil
+	}
+
+	// Another dce process may be migrating or writing the config file,
+	// so check again which file to migrate, once we hold the lock
+	if err := os.MkdirAll(filepath.Dir(u.ConfigFile), 0700); err != nil {
+		return nil, err
+	}
+	unlock, err := u.lockConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %s", u.ConfigFile, err)
+	}
+	defer unlock()
+
+	source := u.migrationSource(legacyFiles)
+	if source == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	migrated, applied, err := configs.Migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	if source != u.ConfigFile {
+		applied = append([]string{fmt.Sprintf("moved %s to %s", source, u.ConfigFile)}, applied...)
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	// Keep the original file, in case anything goes wrong
+	backup := fmt.Sprintf("%s.%s.bak", source, time.Now().Format("20060102150405"))
+	if err := ioutil.WriteFile(backup, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to back up %s: %s", source, err)
+	}
+	if err := writeFileAtomic(u.ConfigFile, migrated, 0600); err != nil {
+		return nil, err
+	}
+	if source != u.ConfigFile {
+		if err := os.Remove(source); err != nil {
+			return nil, err
+		}
+	}
+	return append(applied, "backed up the original config file to "+backup), nil
+}
+
+// migrationSource returns the config file, or else the first of the
+// legacyFiles which exists. Returns "" if there is no config file.
+func (u *FileSystemUtil) migrationSource(legacyFiles []string) string {
+	if u.IsExistingFile(u.ConfigFile) {
+		return u.ConfigFile
+	}
+	for _, legacy := range legacyFiles {
+		if u.IsExistingFile(legacy) {
+			return legacy
+		}
+	}
+	return ""
+}
@@ -2,6 +2,8 @@ package util
 
 import (
 	"fmt"
+	"os"
+
 	"github.com/Optum/dce-cli/configs"
 	observ "github.com/Optum/dce-cli/internal/observation"
 	"github.com/chzyer/readline"
@@ -35,3 +37,27 @@ func (u *PromptUtil) PromptBasic(label string, validator func(input string) erro
 
 	return &input
 }
+
+func (u *PromptUtil) PromptSecret(label string) *string {
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:                 fmt.Sprint(label, " "),
+		DisableAutoSaveHistory: true,
+		EnableMask:             true,
+	})
+	defer rl.Close() //nolint,errcheck
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	input, err := rl.Readline()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return &input
+}
+
+// IsInteractive checks if stdin is a terminal,
+// which can be used to prompt the user
+func (u *PromptUtil) IsInteractive() bool {
+	return readline.IsTerminal(int(os.Stdin.Fd()))
+}
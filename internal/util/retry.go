@@ -0,0 +1,163 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Optum/dce-cli/configs"
+)
+
+// RetryPolicy configures how API requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for each request, including the first
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on each retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used for API requests, unless configured otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// NewRetryPolicy returns the retry policy from the DCE config,
+// using the default policy for any values not configured
+func NewRetryPolicy(config configs.Retry) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+	if config.MaxAttempts != nil {
+		if *config.MaxAttempts < 1 {
+			return policy, fmt.Errorf("api.retry.maxAttempts must be at least 1, got %d", *config.MaxAttempts)
+		}
+		policy.MaxAttempts = *config.MaxAttempts
+	}
+	if config.BaseDelay != nil {
+		delay, err := time.ParseDuration(*config.BaseDelay)
+		if err != nil {
+			return policy, fmt.Errorf("invalid api.retry.baseDelay: %s", err)
+		}
+		policy.BaseDelay = delay
+	}
+	if config.MaxDelay != nil {
+		delay, err := time.ParseDuration(*config.MaxDelay)
+		if err != nil {
+			return policy, fmt.Errorf("invalid api.retry.maxDelay: %s", err)
+		}
+		policy.MaxDelay = delay
+	}
+	return policy, nil
+}
+
+// Backoff returns the delay before the given retry (starting from 1),
+// using exponential backoff with "full jitter", so that many clients
+// throttled at the same time don't all retry at the same time.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	delay := p.MaxDelay
+	if shift := uint(retry - 1); shift < 32 {
+		if exp := p.BaseDelay << shift; exp > 0 && exp < p.MaxDelay {
+			delay = exp
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// isRetryableResponse returns true for responses which indicate
+// a temporary failure.
+//
+// Throttled (429) and unavailable (503) requests were not processed by the API,
+// so are safe to retry for any method. Gateway errors (502, 504) may occur
+// after the request was processed, so are only retried for idempotent methods.
+func isRetryableResponse(method string, res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	default:
+		return false
+	}
+}
+
+// isRetryableError returns true for transient network errors.
+// Requests which fail before a connection is made are safe to retry for any method.
+func isRetryableError(method string, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	if !isIdempotent(method) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && (netErr.Timeout() || netErr.Temporary())
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses the `Retry-After` header,
+// which may be either a number of seconds, or an HTTP date.
+// The delay is capped by MaxDelay, so that a misbehaving server
+// cannot make dce wait for longer than the user configured.
+func (p RetryPolicy) retryAfter(res *http.Response) (time.Duration, bool) {
+	header := res.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay, true
+}
+
+// sleepContext waits for the delay, or until the context is done.
+// Returns false if the delay would outlast the context deadline.
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -4,27 +4,70 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 
+	"github.com/Optum/dce-cli/configs"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 )
 
-func NewAWSSession(token *string) (*session.Session, error) {
-	// Setup the AWS credentials provider chain.
-	// First, we'll check for credentials in the
-	// configuration file's `api.token` config.
-	// then we'll use AWS's standard chain (env vars, ~/aws/credentials file)
-	creds := credentials.NewChainCredentials([]credentials.Provider{
-		NewAPITokenProvider(token),
-		&credentials.EnvProvider{},
-		&credentials.SharedCredentialsProvider{},
-	})
-	return session.NewSession(&aws.Config{
-		Credentials: creds,
-	})
+type NewAWSSessionInput struct {
+	store  CredentialStore
+	aws    configs.AWS
+	region *string
+	// mfaToken prompts for an MFA token, when assuming a role which requires MFA
+	mfaToken func() (string, error)
+}
+
+func NewAWSSession(input *NewAWSSessionInput) (*session.Session, error) {
+	config := &aws.Config{Region: input.region}
+
+	var creds *credentials.Credentials
+	if input.aws.Profile != nil && *input.aws.Profile != "" {
+		// Use the AWS CLI profile, including any role
+		// or source profile configured for it in ~/.aws/config
+		profileSession, err := session.NewSessionWithOptions(session.Options{
+			Config:                  *config,
+			Profile:                 *input.aws.Profile,
+			SharedConfigState:       session.SharedConfigEnable,
+			AssumeRoleTokenProvider: input.mfaToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS profile \"%s\": %s", *input.aws.Profile, err)
+		}
+		creds = profileSession.Config.Credentials
+	} else {
+		// Setup the AWS credentials provider chain.
+		// First, we'll check for credentials in the
+		// API token saved by `dce auth`.
+		// then we'll use AWS's standard chain (env vars, ~/aws/credentials file)
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			NewAPITokenProvider(input.store),
+			&credentials.EnvProvider{},
+			&credentials.SharedCredentialsProvider{},
+		})
+	}
+
+	// Assume each role in turn, using the credentials of the previous role
+	for i, roleARN := range input.aws.RoleARNs {
+		roleSession, err := session.NewSession(config.Copy().WithCredentials(creds))
+		if err != nil {
+			return nil, err
+		}
+		useMFA := i == 0 && input.aws.MFASerial != nil && *input.aws.MFASerial != ""
+		creds = stscreds.NewCredentials(roleSession, roleARN, func(p *stscreds.AssumeRoleProvider) {
+			if useMFA {
+				p.SerialNumber = input.aws.MFASerial
+				p.TokenProvider = input.mfaToken
+			}
+		})
+	}
+
+	return session.NewSession(config.WithCredentials(creds))
 }
 
 // APITokenProvider is a custom AWS Credentials provider
@@ -36,36 +79,34 @@ func NewAWSSession(token *string) (*session.Session, error) {
 // - The `Retrieve` method is cached by the client, so we don't need to re-parse our API token at every call
 // - Provides a mechanism for handling expired creds
 type APITokenProvider struct {
-	token      *string
+	store      CredentialStore
 	expiration int64
 }
 
 const APITokenProviderName = "APITokenProvider"
 
-func NewAPITokenProvider(token *string) credentials.Provider {
+// NewAPITokenProvider returns a provider which reads
+// the API token from the credential store
+func NewAPITokenProvider(store CredentialStore) credentials.Provider {
 	return &APITokenProvider{
-		token: token,
+		store: store,
 	}
 }
 
 func (t *APITokenProvider) Retrieve() (credentials.Value, error) {
-	if t.token == nil {
+	token, err := t.store.GetToken()
+	if err != nil {
 		return credentials.Value{ProviderName: APITokenProviderName},
-			errors.New("no API token is configured")
+			fmt.Errorf("failed to read API token: %s", err)
 	}
-
-	stsTokenJSON, err := base64.StdEncoding.DecodeString(*t.token)
-	if err != nil {
+	if token == nil {
 		return credentials.Value{ProviderName: APITokenProviderName},
-			errors.New("failed to decode token")
+			errors.New("no API token is configured")
 	}
 
-	// Unmarshal the STS Token JSON
-	var tokenValue APITokenValue
-	err = json.Unmarshal(stsTokenJSON, &tokenValue)
+	tokenValue, err := DecodeAPIToken(*token)
 	if err != nil {
-		return credentials.Value{ProviderName: APITokenProviderName},
-			errors.New("decoded token contains invalid JSON")
+		return credentials.Value{ProviderName: APITokenProviderName}, err
 	}
 
 	// Remember the tokens `expired` time,
@@ -89,6 +130,23 @@ func (t *APITokenProvider) IsExpired() bool {
 	return time.Now().Unix() > t.expiration
 }
 
+// DecodeAPIToken decodes a base64 encoded API token,
+// containing STS credentials as JSON
+func DecodeAPIToken(token string) (*APITokenValue, error) {
+	stsTokenJSON, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("failed to decode token")
+	}
+
+	// Unmarshal the STS Token JSON
+	var tokenValue APITokenValue
+	err = json.Unmarshal(stsTokenJSON, &tokenValue)
+	if err != nil {
+		return nil, errors.New("decoded token contains invalid JSON")
+	}
+	return &tokenValue, nil
+}
+
 type APITokenValue struct {
 	AccessKeyID     string `json:"accessKeyId"`
 	SecretAccessKey string `json:"secretAccessKey"`
@@ -16,6 +16,7 @@ import (
 	"github.com/Optum/dce-cli/configs"
 	"github.com/Optum/dce-cli/internal/constants"
 	observ "github.com/Optum/dce-cli/internal/observation"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/pkg/errors"
 )
 
@@ -30,6 +31,7 @@ type execInput struct {
 	Args    []string // Arguments to pass to the command
 	Dir     string   // Working directory
 	Timeout float64  // Max execution time (seconds) of the command
+	Env     []string // Environment variables to add to the current environment
 }
 
 // ParseOptions parses the given options into an array of strings. It provides for any whitespace between
@@ -75,6 +77,9 @@ func execCommand(input *execInput, stdout io.Writer, stderr io.Writer) error {
 	if input.Dir != "" {
 		cmd.Dir = input.Dir
 	}
+	if len(input.Env) > 0 {
+		cmd.Env = append(os.Environ(), input.Env...)
+	}
 
 	if stdout == nil {
 		log.Warnln("stdout: no file supplied; using STDOUT")
@@ -133,6 +138,9 @@ type TerraformBinUtil struct {
 	Observation *observ.ObservationContainer
 	FileSystem  TerraformBinFileSystemUtil
 	Downloader  TerraformBinDownloader
+	// Credentials are passed to terraform as env vars.
+	// If nil, terraform finds AWS credentials itself
+	Credentials *credentials.Credentials
 }
 
 // bin returns the binary path
@@ -148,6 +156,22 @@ func (t *TerraformBinUtil) bin() string {
 	return *bin
 }
 
+// env returns the AWS credentials env vars for terraform
+func (t *TerraformBinUtil) env() ([]string, error) {
+	if t.Credentials == nil {
+		return nil, nil
+	}
+	creds, err := t.Credentials.Get()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get AWS credentials for terraform")
+	}
+	return []string{
+		"AWS_ACCESS_KEY_ID=" + creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN=" + creds.SessionToken,
+	}, nil
+}
+
 // source returns the download URL for terraform binary.
 func (t *TerraformBinUtil) source() string {
 	source := t.Config.Terraform.Source
@@ -197,11 +221,17 @@ func (t *TerraformBinUtil) Init(ctx context.Context, args []string) error {
 		t.FileSystem.RemoveAll(archive)
 	}
 
+	env, err := t.env()
+	if err != nil {
+		return err
+	}
+
 	// at this point, the binary should exist. Call `init`
 	execArgs := &execInput{
 		Name: t.bin(),
 		Args: argv,
 		Dir:  t.FileSystem.GetLocalTFModuleDir(),
+		Env:  env,
 	}
 
 	return execCommand(execArgs, logFile, logFile)
@@ -229,10 +259,16 @@ func (t *TerraformBinUtil) Apply(ctx context.Context, args []string) error {
 	argv := []string{"apply", "-no-color", "-auto-approve", "-input=false"}
 	argv = append(argv, args...)
 
+	env, err := t.env()
+	if err != nil {
+		return err
+	}
+
 	execArgs := &execInput{
 		Name: t.bin(),
 		Args: argv,
 		Dir:  t.FileSystem.GetLocalTFModuleDir(),
+		Env:  env,
 	}
 
 	return execCommand(execArgs, logFile, logFile)
@@ -255,6 +291,11 @@ func (t *TerraformBinUtil) GetOutput(ctx context.Context, key string) (string, e
 		defer logFile.Close()
 	}
 
+	env, err := t.env()
+	if err != nil {
+		return "", err
+	}
+
 	// Run `terraform output` command
 	err = execCommand(&execInput{
 		Name: t.bin(),
@@ -264,6 +305,7 @@ func (t *TerraformBinUtil) GetOutput(ctx context.Context, key string) (string, e
 			"-no-color",
 		},
 		Dir: t.FileSystem.GetLocalTFModuleDir(),
+		Env: env,
 	},
 		&stdout,
 		logFile)
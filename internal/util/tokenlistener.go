@@ -0,0 +1,114 @@
+package util
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// TokenListenerUtil receives API tokens from the DCE auth page,
+// by running a temporary HTTP server on localhost.
+type TokenListenerUtil struct{}
+
+// ListenForToken starts an HTTP server on a random localhost port,
+// and calls `open` with the URL which the auth page should redirect to.
+//
+// The auth page redirects the browser to the URL, with `token` and `state` query params.
+// Requests with an unexpected `state` are ignored, to prevent other pages from
+// sending tokens to the server. The token is checked with `validate`,
+// and the server is shut down once a token is received, or the context is done.
+func (t *TokenListenerUtil) ListenForToken(ctx context.Context, open func(redirectURL string), validate func(token string) error) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to start localhost server: %s", err)
+	}
+
+	state, err := randomState()
+	if err != nil {
+		_ = listener.Close()
+		return "", err
+	}
+
+	results := make(chan tokenResult, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.FormValue("state")), []byte(state)) != 1 {
+			http.Error(w, "Invalid state. Please try logging in again.", http.StatusBadRequest)
+			return
+		}
+
+		token := r.FormValue("token")
+		if err := validate(token); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			writeTokenPage(w, "Login failed", fmt.Sprintf("Invalid API token: %s", err))
+			sendResult(results, tokenResult{err: fmt.Errorf("invalid API token: %s", err)})
+			return
+		}
+
+		writeTokenPage(w, "Login successful", "You may close this window, and return to your terminal.")
+		sendResult(results, tokenResult{token: token})
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			sendResult(results, tokenResult{err: fmt.Errorf("localhost server failed: %s", err)})
+		}
+	}()
+	defer server.Close()
+
+	redirectURL := url.URL{
+		Scheme:   "http",
+		Host:     listener.Addr().String(),
+		Path:     "/callback",
+		RawQuery: url.Values{"state": {state}}.Encode(),
+	}
+	open(redirectURL.String())
+
+	select {
+	case res := <-results:
+		return res.token, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", errors.New("timed out waiting for login to complete")
+		}
+		return "", ctx.Err()
+	}
+}
+
+type tokenResult struct {
+	token string
+	err   error
+}
+
+// sendResult sends the first result received,
+// and drops any later results
+func sendResult(results chan<- tokenResult, res tokenResult) {
+	select {
+	case results <- res:
+	default:
+	}
+}
+
+func randomState() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate login state: %s", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func writeTokenPage(w http.ResponseWriter, title string, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>DCE - %[1]s</title></head>"+
+		"<body><h1>%[1]s</h1><p>%[2]s</p></body></html>",
+		html.EscapeString(title), html.EscapeString(message))
+}
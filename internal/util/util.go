@@ -2,11 +2,13 @@ package util
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 
 	"github.com/Optum/dce-cli/configs"
 	observ "github.com/Optum/dce-cli/internal/observation"
@@ -19,6 +21,9 @@ type UtilContainer struct {
 	ConfigFile  string
 	Observation *observ.ObservationContainer
 	AWSSession  *session.Session
+	// Reauthenticate is called to login again, when the DCE API rejects
+	// our credentials as expired. It is set by the command layer.
+	Reauthenticate func() error
 	AWSer
 	APIer
 	Terraformer
@@ -28,6 +33,8 @@ type UtilContainer struct {
 	Weber
 	Durationer
 	TFTemplater
+	TokenListener
+	CredentialStore
 }
 
 var log observ.Logger
@@ -35,42 +42,78 @@ var log observ.Logger
 // New returns a new Util given config
 func New(config *configs.Root, configFile string, observation *observ.ObservationContainer) *UtilContainer {
 	log = observation.Logger
+	awsConfig := config.AWS
 
-	var awsSession *session.Session
-	awsSession, err := NewAWSSession(config.API.Token)
+	filesystem := &FileSystemUtil{Config: config, ConfigFile: configFile}
+	filesystem.snapshotConfig()
+	weber := &WebUtil{Observation: observation}
+	terraformer := &TerraformBinUtil{Config: config, Observation: observation, FileSystem: filesystem, Downloader: weber}
+
+	utilContainer := UtilContainer{
+		Config:        config,
+		Observation:   observation,
+		Terraformer:   terraformer,
+		Githuber:      &GithubUtil{Config: config, Observation: observation},
+		Prompter:      &PromptUtil{Config: config, Observation: observation},
+		FileSystemer:  filesystem,
+		Weber:         weber,
+		Durationer:    NewDurationUtil(),
+		TokenListener: &TokenListenerUtil{},
+	}
+
+	// Prompt for the passphrase of the encrypted token file,
+	// unless set by env var
+	passphrase := func() (string, error) {
+		if val, ok := os.LookupEnv(TokenPassphraseEnv); ok {
+			return val, nil
+		}
+		return *utilContainer.PromptSecret("API token passphrase:"), nil
+	}
+	credentialStore, err := NewCredentialStore(config, filesystem, passphrase)
+	if err != nil {
+		log.Fatalf("Invalid credential store config: %s", err)
+	}
+	utilContainer.CredentialStore = credentialStore
+
+	awsSession, err := NewAWSSession(&NewAWSSessionInput{
+		store:  credentialStore,
+		aws:    awsConfig,
+		region: config.Region,
+		mfaToken: func() (string, error) {
+			return *utilContainer.PromptBasic("Enter MFA token code:", nil), nil
+		},
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize AWS Session: %s", err)
 	}
-	awsSession.Config.Region = config.Region
+	utilContainer.AWSSession = awsSession
+	if len(awsConfig.RoleARNs) > 0 || (awsConfig.Profile != nil && *awsConfig.Profile != "") {
+		// Terraform should use the same credentials as DCE,
+		// rather than the default AWS credentials
+		terraformer.Credentials = awsSession.Config.Credentials
+	}
+	utilContainer.AWSer = &AWSUtil{Config: config, Observation: observation, Session: awsSession}
 
-	var apiClient APIer
 	if config.API.Host != nil && config.API.BasePath != nil {
-		apiClient = NewAPIClient(&NewAPIClientInput{
+		retryPolicy, err := NewRetryPolicy(config.API.Retry)
+		if err != nil {
+			log.Fatalf("Invalid API retry config: %s", err)
+		}
+		utilContainer.APIer = NewAPIClient(&NewAPIClientInput{
 			credentials: awsSession.Config.Credentials,
 			region:      config.Region,
 			host:        config.API.Host,
 			basePath:    config.API.BasePath,
-			token:       config.API.Token,
+			retry:       retryPolicy,
+			reauthenticate: func() error {
+				if utilContainer.Reauthenticate == nil {
+					return errors.New("re-authentication is not supported")
+				}
+				return utilContainer.Reauthenticate()
+			},
 		})
 	}
 
-	filesystem := &FileSystemUtil{Config: config, ConfigFile: configFile}
-	weber := &WebUtil{Observation: observation}
-
-	utilContainer := UtilContainer{
-		Config:       config,
-		Observation:  observation,
-		AWSSession:   awsSession,
-		AWSer:        &AWSUtil{Config: config, Observation: observation, Session: awsSession},
-		APIer:        apiClient,
-		Terraformer:  &TerraformBinUtil{Config: config, Observation: observation, FileSystem: filesystem, Downloader: weber},
-		Githuber:     &GithubUtil{Config: config, Observation: observation},
-		Prompter:     &PromptUtil{Config: config, Observation: observation},
-		FileSystemer: filesystem,
-		Weber:        weber,
-		Durationer:   NewDurationUtil(),
-	}
-
 	utilContainer.TFTemplater = NewMainTFTemplate(utilContainer.FileSystemer)
 
 	return &utilContainer
@@ -79,7 +122,10 @@ func New(config *configs.Root, configFile string, observation *observ.Observatio
 type AWSer interface {
 	UploadDirectoryToS3(localPath string, bucket string, prefix string) ([]string, []string)
 	UpdateLambdasFromS3Assets(lambdaNames []string, bucket string, namespace string)
-	ConfigureAWSCLICredentials(accessKeyID, secretAccessKey, sessionToken, profile string)
+	ConfigureAWSCLICredentials(creds *AWSCLICredentials) error
+	RemoveAWSCLIProfile(profile string) error
+	SetAWSConfigProfile(profile string, settings map[string]string) error
+	GetCallerIdentity(ctx context.Context) (*sts.GetCallerIdentityOutput, error)
 }
 
 type Terraformer interface {
@@ -94,6 +140,10 @@ type Githuber interface {
 
 type Prompter interface {
 	PromptBasic(label string, validator func(input string) error) *string
+	// PromptSecret prompts for input without echoing it, eg. for a passphrase
+	PromptSecret(label string) *string
+	// IsInteractive returns false if the user cannot be prompted, eg. when stdin is not a terminal
+	IsInteractive() bool
 }
 
 type FileSystemer interface {
@@ -148,6 +198,12 @@ type Weber interface {
 	OpenURL(url string)
 }
 
+// TokenListener receives API tokens from the DCE auth page,
+// which redirects the browser to a temporary localhost server
+type TokenListener interface {
+	ListenForToken(ctx context.Context, open func(redirectURL string), validate func(token string) error) (string, error)
+}
+
 // Durationer is an interface for exanding strings into times.
 type Durationer interface {
 	ExpandEpochTime(str string) (int64, error)
@@ -2,16 +2,79 @@
 
 package mocks
 
+import context "context"
+import util "github.com/Optum/dce-cli/internal/util"
 import mock "github.com/stretchr/testify/mock"
+import sts "github.com/aws/aws-sdk-go/service/sts"
 
 // AWSer is an autogenerated mock type for the AWSer type
 type AWSer struct {
 	mock.Mock
 }
 
-// ConfigureAWSCLICredentials provides a mock function with given fields: accessKeyID, secretAccessKey, sessionToken, profile
-func (_m *AWSer) ConfigureAWSCLICredentials(accessKeyID string, secretAccessKey string, sessionToken string, profile string) {
-	_m.Called(accessKeyID, secretAccessKey, sessionToken, profile)
+// ConfigureAWSCLICredentials provides a mock function with given fields: creds
+func (_m *AWSer) ConfigureAWSCLICredentials(creds *util.AWSCLICredentials) error {
+	ret := _m.Called(creds)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*util.AWSCLICredentials) error); ok {
+		r0 = rf(creds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCallerIdentity provides a mock function with given fields: ctx
+func (_m *AWSer) GetCallerIdentity(ctx context.Context) (*sts.GetCallerIdentityOutput, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *sts.GetCallerIdentityOutput
+	if rf, ok := ret.Get(0).(func(context.Context) *sts.GetCallerIdentityOutput); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sts.GetCallerIdentityOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveAWSCLIProfile provides a mock function with given fields: profile
+func (_m *AWSer) RemoveAWSCLIProfile(profile string) error {
+	ret := _m.Called(profile)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(profile)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetAWSConfigProfile provides a mock function with given fields: profile, settings
+func (_m *AWSer) SetAWSConfigProfile(profile string, settings map[string]string) error {
+	ret := _m.Called(profile, settings)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, map[string]string) error); ok {
+		r0 = rf(profile, settings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // UpdateLambdasFromS3Assets provides a mock function with given fields: lambdaNames, bucket, namespace
@@ -2,29 +2,67 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
+import service "github.com/Optum/dce-cli/pkg/service"
 
 // Accounter is an autogenerated mock type for the Accounter type
 type Accounter struct {
 	mock.Mock
 }
 
-// AddAccount provides a mock function with given fields: accountID, adminRoleARN
-func (_m *Accounter) AddAccount(accountID string, adminRoleARN string) {
-	_m.Called(accountID, adminRoleARN)
+// AddAccount provides a mock function with given fields: ctx, accountID, adminRoleARN
+func (_m *Accounter) AddAccount(ctx context.Context, accountID string, adminRoleARN string) error {
+	ret := _m.Called(ctx, accountID, adminRoleARN)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, accountID, adminRoleARN)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// GetAccount provides a mock function with given fields: accountID
-func (_m *Accounter) GetAccount(accountID string) {
-	_m.Called(accountID)
+// GetAccount provides a mock function with given fields: ctx, accountID
+func (_m *Accounter) GetAccount(ctx context.Context, accountID string) error {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// ListAccounts provides a mock function with given fields:
-func (_m *Accounter) ListAccounts() {
-	_m.Called()
+// ListAccounts provides a mock function with given fields: ctx, opts
+func (_m *Accounter) ListAccounts(ctx context.Context, opts *service.AccountListOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *service.AccountListOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// RemoveAccount provides a mock function with given fields: accountID
-func (_m *Accounter) RemoveAccount(accountID string) {
-	_m.Called(accountID)
+// RemoveAccount provides a mock function with given fields: ctx, accountID
+func (_m *Accounter) RemoveAccount(ctx context.Context, accountID string) error {
+	ret := _m.Called(ctx, accountID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
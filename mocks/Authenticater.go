@@ -2,15 +2,45 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
+import service "github.com/Optum/dce-cli/pkg/service"
 
 // Authenticater is an autogenerated mock type for the Authenticater type
 type Authenticater struct {
 	mock.Mock
 }
 
-// Authenticate provides a mock function with given fields:
-func (_m *Authenticater) Authenticate() error {
+// AuthStatus provides a mock function with given fields: ctx
+func (_m *Authenticater) AuthStatus(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Authenticate provides a mock function with given fields: ctx, opts
+func (_m *Authenticater) Authenticate(ctx context.Context, opts *service.AuthOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *service.AuthOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Logout provides a mock function with given fields:
+func (_m *Authenticater) Logout() error {
 	ret := _m.Called()
 
 	var r0 error
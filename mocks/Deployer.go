@@ -2,6 +2,7 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import service "github.com/Optum/dce-cli/pkg/service"
 
@@ -10,13 +11,13 @@ type Deployer struct {
 	mock.Mock
 }
 
-// Deploy provides a mock function with given fields: input
-func (_m *Deployer) Deploy(input *service.DeployConfig) error {
-	ret := _m.Called(input)
+// Deploy provides a mock function with given fields: ctx, input
+func (_m *Deployer) Deploy(ctx context.Context, input *service.DeployConfig) error {
+	ret := _m.Called(ctx, input)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*service.DeployConfig) error); ok {
-		r0 = rf(input)
+	if rf, ok := ret.Get(0).(func(context.Context, *service.DeployConfig) error); ok {
+		r0 = rf(ctx, input)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -24,13 +25,13 @@ func (_m *Deployer) Deploy(input *service.DeployConfig) error {
 	return r0
 }
 
-// PostDeploy provides a mock function with given fields: input
-func (_m *Deployer) PostDeploy(input *service.DeployConfig) error {
-	ret := _m.Called(input)
+// PostDeploy provides a mock function with given fields: ctx, input
+func (_m *Deployer) PostDeploy(ctx context.Context, input *service.DeployConfig) error {
+	ret := _m.Called(ctx, input)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*service.DeployConfig) error); ok {
-		r0 = rf(input)
+	if rf, ok := ret.Get(0).(func(context.Context, *service.DeployConfig) error); ok {
+		r0 = rf(ctx, input)
 	} else {
 		r0 = ret.Error(0)
 	}
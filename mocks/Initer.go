@@ -10,6 +10,15 @@ type Initer struct {
 }
 
 // InitializeDCE provides a mock function with given fields:
-func (_m *Initer) InitializeDCE() {
-	_m.Called()
+func (_m *Initer) InitializeDCE() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
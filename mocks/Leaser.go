@@ -2,6 +2,7 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import service "github.com/Optum/dce-cli/pkg/service"
 
@@ -10,32 +11,170 @@ type Leaser struct {
 	mock.Mock
 }
 
-// CreateLease provides a mock function with given fields: principalID, budgetAmount, budgetCurrency, email, expiresOn
-func (_m *Leaser) CreateLease(principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string) {
-	_m.Called(principalID, budgetAmount, budgetCurrency, email, expiresOn)
+// ConfigureCredentialProcess provides a mock function with given fields: leaseID, profile
+func (_m *Leaser) ConfigureCredentialProcess(leaseID string, profile string) error {
+	ret := _m.Called(leaseID, profile)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(leaseID, profile)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateLease provides a mock function with given fields: ctx, principalID, budgetAmount, budgetCurrency, email, expiresOn, opts
+func (_m *Leaser) CreateLease(ctx context.Context, principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string, opts *service.LeaseCreateOptions) error {
+	ret := _m.Called(ctx, principalID, budgetAmount, budgetCurrency, email, expiresOn, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, float64, string, []string, string, *service.LeaseCreateOptions) error); ok {
+		r0 = rf(ctx, principalID, budgetAmount, budgetCurrency, email, expiresOn, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateLeasesFromFile provides a mock function with given fields: ctx, file, overrides, opts
+func (_m *Leaser) CreateLeasesFromFile(ctx context.Context, file string, overrides *service.LeaseSpec, opts *service.LeaseCreateOptions) error {
+	ret := _m.Called(ctx, file, overrides, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *service.LeaseSpec, *service.LeaseCreateOptions) error); ok {
+		r0 = rf(ctx, file, overrides, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialProcess provides a mock function with given fields: ctx, leaseID
+func (_m *Leaser) CredentialProcess(ctx context.Context, leaseID string) error {
+	ret := _m.Called(ctx, leaseID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, leaseID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EndLease provides a mock function with given fields: ctx, leaseID, accountID, principalID
+func (_m *Leaser) EndLease(ctx context.Context, leaseID string, accountID string, principalID string) error {
+	ret := _m.Called(ctx, leaseID, accountID, principalID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, leaseID, accountID, principalID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// EndLease provides a mock function with given fields: accountID, principalID
-func (_m *Leaser) EndLease(accountID string, principalID string) {
-	_m.Called(accountID, principalID)
+// Exec provides a mock function with given fields: ctx, leaseID, command
+func (_m *Leaser) Exec(ctx context.Context, leaseID string, command []string) error {
+	ret := _m.Called(ctx, leaseID, command)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, leaseID, command)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLease provides a mock function with given fields: ctx, leaseID
+func (_m *Leaser) GetLease(ctx context.Context, leaseID string) error {
+	ret := _m.Called(ctx, leaseID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, leaseID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListLeases provides a mock function with given fields: ctx, opts
+func (_m *Leaser) ListLeases(ctx context.Context, opts *service.LeaseListOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *service.LeaseListOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// GetLease provides a mock function with given fields: leaseID
-func (_m *Leaser) GetLease(leaseID string) {
-	_m.Called(leaseID)
+// Login provides a mock function with given fields: ctx, opts
+func (_m *Leaser) Login(ctx context.Context, opts *service.LeaseLoginOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *service.LeaseLoginOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// ListLeases provides a mock function with given fields: acctID, principalID, nextAcctID, nextPrincipalID, leaseStatus, pagLimit
-func (_m *Leaser) ListLeases(acctID string, principalID string, nextAcctID string, nextPrincipalID string, leaseStatus string, pagLimit int64) {
-	_m.Called(acctID, principalID, nextAcctID, nextPrincipalID, leaseStatus, pagLimit)
+// LoginByID provides a mock function with given fields: ctx, leaseID, opts
+func (_m *Leaser) LoginByID(ctx context.Context, leaseID string, opts *service.LeaseLoginOptions) error {
+	ret := _m.Called(ctx, leaseID, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *service.LeaseLoginOptions) error); ok {
+		r0 = rf(ctx, leaseID, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// Login provides a mock function with given fields: opts
-func (_m *Leaser) Login(opts *service.LeaseLoginOptions) {
-	_m.Called(opts)
+// Shell provides a mock function with given fields: ctx, leaseID
+func (_m *Leaser) Shell(ctx context.Context, leaseID string) error {
+	ret := _m.Called(ctx, leaseID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, leaseID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// LoginByID provides a mock function with given fields: leaseID, opts
-func (_m *Leaser) LoginByID(leaseID string, opts *service.LeaseLoginOptions) {
-	_m.Called(leaseID, opts)
+// WatchLease provides a mock function with given fields: ctx, leaseID, opts
+func (_m *Leaser) WatchLease(ctx context.Context, leaseID string, opts *service.LeaseWatchOptions) error {
+	ret := _m.Called(ctx, leaseID, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *service.LeaseWatchOptions) error); ok {
+		r0 = rf(ctx, leaseID, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
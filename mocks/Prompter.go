@@ -9,6 +9,20 @@ type Prompter struct {
 	mock.Mock
 }
 
+// IsInteractive provides a mock function with given fields:
+func (_m *Prompter) IsInteractive() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // PromptBasic provides a mock function with given fields: label, validator
 func (_m *Prompter) PromptBasic(label string, validator func(string) error) *string {
 	ret := _m.Called(label, validator)
@@ -25,6 +39,22 @@ func (_m *Prompter) PromptBasic(label string, validator func(string) error) *str
 	return r0
 }
 
+// PromptSecret provides a mock function with given fields: label
+func (_m *Prompter) PromptSecret(label string) *string {
+	ret := _m.Called(label)
+
+	var r0 *string
+	if rf, ok := ret.Get(0).(func(string) *string); ok {
+		r0 = rf(label)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*string)
+		}
+	}
+
+	return r0
+}
+
 // PromptSelect provides a mock function with given fields: label, items
 func (_m *Prompter) PromptSelect(label string, items []string) *string {
 	ret := _m.Called(label, items)
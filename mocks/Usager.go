@@ -2,6 +2,7 @@
 
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 
 // Usager is an autogenerated mock type for the Usager type
@@ -9,7 +10,16 @@ type Usager struct {
 	mock.Mock
 }
 
-// GetUsage provides a mock function with given fields: startDate, endDate
-func (_m *Usager) GetUsage(startDate float64, endDate float64) {
-	_m.Called(startDate, endDate)
+// GetUsage provides a mock function with given fields: ctx, startDate, endDate
+func (_m *Usager) GetUsage(ctx context.Context, startDate float64, endDate float64) error {
+	ret := _m.Called(ctx, startDate, endDate)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, float64, float64) error); ok {
+		r0 = rf(ctx, startDate, endDate)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
@@ -1,13 +1,14 @@
 package service
 
 import (
-	"encoding/json"
-	"time"
+	"context"
+	"net/url"
 
 	"github.com/Optum/dce-cli/client/operations"
 	"github.com/Optum/dce-cli/configs"
 	observ "github.com/Optum/dce-cli/internal/observation"
 	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/Optum/dce-cli/models"
 )
 
 type AccountsService struct {
@@ -16,66 +17,96 @@ type AccountsService struct {
 	Util        *utl.UtilContainer
 }
 
-func (s *AccountsService) AddAccount(accountID, adminRoleARN string) {
+func (s *AccountsService) AddAccount(ctx context.Context, accountID, adminRoleARN string) error {
 	params := &operations.PostAccountsParams{
 		Account: operations.PostAccountsBody{
 			ID:           &accountID,
 			AdminRoleArn: &adminRoleARN,
 		},
 	}
-	params.SetTimeout(5 * time.Second)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	params.SetContext(reqCtx)
 	_, err := ApiClient.PostAccounts(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
-	} else {
-		log.Infoln("Account added to DCE accounts pool")
+		return apiError("add account", err)
 	}
+	log.Infoln("Account added to DCE accounts pool")
+	return nil
 }
 
-func (s *AccountsService) RemoveAccount(accountID string) {
+func (s *AccountsService) RemoveAccount(ctx context.Context, accountID string) error {
 	params := &operations.DeleteAccountsIDParams{
 		ID: accountID,
 	}
-	params.SetTimeout(5 * time.Second)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	params.SetContext(reqCtx)
 	_, err := ApiClient.DeleteAccountsID(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
-	} else {
-		log.Infoln("Account removed from DCE accounts pool")
+		return apiError("remove account", err)
 	}
+	log.Infoln("Account removed from DCE accounts pool")
+	return nil
 }
 
-func (s *AccountsService) GetAccount(accountID string) {
+func (s *AccountsService) GetAccount(ctx context.Context, accountID string) error {
 	params := &operations.GetAccountsIDParams{
 		ID: accountID,
 	}
-	params.SetTimeout(5 * time.Second)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	params.SetContext(reqCtx)
 	res, err := ApiClient.GetAccountsID(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
-	}
-	jsonPayload, err := json.MarshalIndent(res.GetPayload(), "", "\t")
-	if err != nil {
-		log.Fatalln("err: ", err)
-	}
-	if _, err := Out.Write(jsonPayload); err != nil {
-		log.Fatalln("err: ", err)
+		return apiError("get account", err)
 	}
+	account := models.Account(*res.GetPayload())
+	return writeOutput(&account)
 }
 
 // ListAccounts lists the accounts
-func (s *AccountsService) ListAccounts() {
-	params := &operations.GetAccountsParams{}
-	params.SetTimeout(5 * time.Second)
-	res, err := ApiClient.GetAccounts(params, nil)
-	if err != nil {
-		log.Fatalln("err: ", err)
+func (s *AccountsService) ListAccounts(ctx context.Context, opts *AccountListOptions) error {
+	params := &operations.GetAccountsParams{
+		Status:              &opts.Status,
+		AdminRoleArn:        &opts.AdminRoleArn,
+		PrincipalRoleArn:    &opts.PrincipalRoleArn,
+		PrincipalPolicyHash: &opts.PrincipalPolicyHash,
+		NextID:              &opts.NextID,
+	}
+	if opts.Limit > 0 {
+		params.Limit = &opts.Limit
 	}
-	jsonPayload, err := json.MarshalIndent(res.GetPayload(), "", "\t")
+
+	accounts := []*models.Account{}
+	more, err := paginate(opts.All, opts.MaxItems, func(next url.Values) (string, int64, error) {
+		if next != nil {
+			params.NextID = pageParam(next, "nextId")
+		}
+		reqCtx, cancel := requestContext(ctx)
+		defer cancel()
+		params.SetContext(reqCtx)
+		res, err := ApiClient.GetAccounts(params, nil)
+		if err != nil {
+			return "", 0, apiError("list accounts", err)
+		}
+		for _, item := range res.GetPayload() {
+			account := models.Account(*item)
+			accounts = append(accounts, &account)
+		}
+		return res.Link, int64(len(accounts)), nil
+	})
 	if err != nil {
-		log.Fatalln("err: ", err)
+		return err
+	}
+
+	if opts.MaxItems > 0 && int64(len(accounts)) > opts.MaxItems {
+		accounts = accounts[:opts.MaxItems]
 	}
-	if _, err := Out.Write(jsonPayload); err != nil {
-		log.Fatalln("err: ", err)
+	if more != nil {
+		log.Infof("More accounts are available. Use --all to list them, "+
+			"or use --next-id %s to view the next page.\n", more.Get("nextId"))
 	}
+
+	return writeOutput(accounts)
 }
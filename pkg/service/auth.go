@@ -1,8 +1,7 @@
 package service
 
 import (
-	"errors"
-	"fmt"
+	"context"
 	"net/url"
 	"path"
 	"time"
@@ -10,50 +9,217 @@ import (
 	"github.com/Optum/dce-cli/configs"
 	observ "github.com/Optum/dce-cli/internal/observation"
 	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/aws/aws-sdk-go/aws"
 )
 
+const (
+	// AuthModePaste prompts the user to copy/paste the API token from the auth page
+	AuthModePaste = configs.AuthModePaste
+	// AuthModeLoopback receives the API token from the auth page,
+	// via a redirect to a temporary localhost server
+	AuthModeLoopback = configs.AuthModeLoopback
+)
+
+// AuthModes are the supported values of AuthOptions.Mode
+var AuthModes = configs.AuthModes
+
+// loopbackAuthTimeout is how long to wait for the user to login,
+// when using AuthModeLoopback
+const loopbackAuthTimeout = 5 * time.Minute
+
+type AuthOptions struct {
+	// Mode sets how to receive the API token from the auth page.
+	// Defaults to the `api.authMode` config, or AuthModePaste
+	Mode string
+}
+
 type AuthService struct {
 	Config      *configs.Root
 	Observation *observ.ObservationContainer
 	Util        *utl.UtilContainer
 }
 
-func (s *AuthService) Authenticate() error {
+func (s *AuthService) Authenticate(ctx context.Context, opts *AuthOptions) error {
 	// Check that our API is configured properly
 	if s.Config.API.Host == nil || s.Config.API.BasePath == nil {
-		return errors.New("Unable to authenticate against DCE API: missing API configuration")
+		return NewValidationError("Unable to authenticate against DCE API: missing API configuration")
 	}
 
-	log.Println("Opening web browser. Please Login and copy/paste the provided token into this terminal.")
-	// Wait a moment, so the user can see our message, and know what's going on
-	time.Sleep(1 * time.Second)
+	mode := AuthModePaste
+	if opts != nil && opts.Mode != "" {
+		mode = opts.Mode
+	} else if s.Config.API.AuthMode != nil && *s.Config.API.AuthMode != "" {
+		mode = *s.Config.API.AuthMode
+	}
 
 	// Open the DCE API's /auth URL
 	// this will use Cognito to redirect the user to
 	// their configured IDP, then back to the /auth page,
-	// which will display a "auth code" to the end-user.
-	// The user will then need to copy the auth code
-	// into their CLI prompt.
+	// which will provide an "auth code" to the CLI.
 	authUrl := url.URL{
 		Scheme: "https",
 		Host:   *s.Config.API.Host,
 		Path:   path.Join(*s.Config.API.BasePath, "/auth"),
 	}
+
+	var authCode *string
+	switch mode {
+	case AuthModePaste:
+		authCode = s.promptForToken(authUrl)
+	case AuthModeLoopback:
+		token, err := s.listenForToken(ctx, authUrl)
+		if err != nil {
+			return newError("login", err)
+		}
+		authCode = &token
+	default:
+		return NewValidationError("invalid auth mode \"%s\": must be one of %v", mode, AuthModes)
+	}
+
+	// Save the token to the configured credential store
+	log.Printf("Saving API Token to %s", s.Util.TokenLocation())
+	err := s.Util.StoreToken(*authCode)
+	if err != nil {
+		return newError("save API token", err)
+	}
+
+	return nil
+}
+
+// promptForToken opens the auth page, which displays the API token
+// to the user. The user then needs to copy the token into their CLI prompt.
+func (s *AuthService) promptForToken(authUrl url.URL) *string {
+	log.Println("Opening web browser. Please Login and copy/paste the provided token into this terminal.")
+	// Wait a moment, so the user can see our message, and know what's going on
+	time.Sleep(1 * time.Second)
+
 	s.Util.OpenURL(authUrl.String())
 
 	// Prompt for the auth code
-	authCode := s.Util.PromptBasic(
+	return s.Util.PromptBasic(
 		"Enter API Token: ", nil,
 	)
+}
+
+// listenForToken opens the auth page with a `redirect_uri` to a localhost server,
+// and waits for the auth page to redirect back with the API token,
+// appended to the `redirect_uri` as a `token` query param.
+func (s *AuthService) listenForToken(ctx context.Context, authUrl url.URL) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, loopbackAuthTimeout)
+	defer cancel()
+
+	return s.Util.ListenForToken(ctx,
+		func(redirectURL string) {
+			query := authUrl.Query()
+			query.Set("redirect_uri", redirectURL)
+			authUrl.RawQuery = query.Encode()
+
+			log.Println("Opening web browser. Please Login, and your API token will be saved automatically.")
+			log.Printf("If your browser does not open, visit %s", authUrl.String())
+			s.Util.OpenURL(authUrl.String())
+		},
+		validateToken,
+	)
+}
+
+// validateToken checks that the API token contains unexpired STS credentials
+func validateToken(token string) error {
+	_, err := utl.NewAPITokenProvider(&utl.StaticCredentialStore{Token: token}).Retrieve()
+	return err
+}
+
+// AuthStatus describes the credentials used to access the DCE API
+type AuthStatus struct {
+	APIEndpoint string `json:"apiEndpoint"`
+	// CredentialProvider is the provider which supplied the credentials,
+	// eg. APITokenProvider for the token saved by `dce auth`
+	CredentialProvider string `json:"credentialProvider,omitempty"`
+	// TokenExpiresOn is the expiry of the API token saved by `dce auth`, as a UNIX epoch
+	TokenExpiresOn int64 `json:"tokenExpiresOn,omitempty"`
+	// TokenExpiresIn is the time remaining until the API token expires, eg. "2h15m0s"
+	TokenExpiresIn string `json:"tokenExpiresIn,omitempty"`
+	Account        string `json:"account,omitempty"`
+	Arn            string `json:"arn,omitempty"`
+	UserID         string `json:"userId,omitempty"`
+}
+
+// AuthStatus writes the status of the credentials used to access the DCE API.
+// Returns an UnauthorizedError if there are no valid credentials.
+func (s *AuthService) AuthStatus(ctx context.Context) error {
+	status := AuthStatus{}
+	if s.Config.API.Host != nil && s.Config.API.BasePath != nil {
+		apiURL := url.URL{
+			Scheme: "https",
+			Host:   *s.Config.API.Host,
+			Path:   *s.Config.API.BasePath,
+		}
+		status.APIEndpoint = apiURL.String()
+	}
+
+	storedToken, err := s.Util.GetToken()
+	if err != nil {
+		log.Warnf("Failed to read API token: %s", err)
+	} else if storedToken != nil {
+		token, err := utl.DecodeAPIToken(*storedToken)
+		if err != nil {
+			log.Warnf("Invalid API token: %s", err)
+		} else {
+			status.TokenExpiresOn = token.Expiration
+			status.TokenExpiresIn = "expired"
+			if remaining := time.Until(time.Unix(token.Expiration, 0)); remaining > 0 {
+				status.TokenExpiresIn = remaining.Truncate(time.Second).String()
+			}
+		}
+	}
 
-	// Update the dce.yml config, with the token
-	log.Printf("Saving API Token to %s", s.Util.GetConfigFile())
-	s.Config.API.Token = authCode
-	err := s.Util.WriteConfig()
+	var credsErr error
+	creds, err := s.Util.AWSSession.Config.Credentials.Get()
 	if err != nil {
-		return fmt.Errorf("Failed to write to %s: %s",
-			s.Util.GetConfigFile(), err)
+		credsErr = &Error{Kind: UnauthorizedError, Op: "find credentials", Err: err,
+			Hint: "Run `dce auth` to log in."}
+	} else {
+		status.CredentialProvider = creds.ProviderName
+
+		reqCtx, cancel := requestContext(ctx)
+		defer cancel()
+		identity, err := s.Util.GetCallerIdentity(reqCtx)
+		if err != nil {
+			credsErr = &Error{Kind: UnauthorizedError, Op: "get caller identity", Err: err,
+				Hint: "Your credentials may be expired. Run `dce auth` to log in again."}
+		} else {
+			status.Account = aws.StringValue(identity.Account)
+			status.Arn = aws.StringValue(identity.Arn)
+			status.UserID = aws.StringValue(identity.UserId)
+		}
+	}
+
+	if err := writeOutput(&status); err != nil {
+		return err
+	}
+	return credsErr
+}
+
+// Logout removes the API token from the credential store,
+// and removes any AWS CLI profiles and cached credentials written by `dce leases`
+func (s *AuthService) Logout() error {
+	for _, profile := range s.Config.AWSProfiles {
+		if err := s.Util.RemoveAWSCLIProfile(profile); err != nil {
+			return newError("remove AWS CLI profile "+profile, err)
+		}
+		log.Infof("Removed AWS CLI profile \"%s\"", profile)
+	}
+
+	// Remove lease credentials cached by `dce leases credential-process`
+	s.Util.RemoveAll(credentialCacheDir(s.Util.GetCacheDir()))
+
+	if err := s.Util.EraseToken(); err != nil {
+		return newError("remove API token from "+s.Util.TokenLocation(), err)
 	}
 
+	s.Config.AWSProfiles = nil
+	if err := s.Util.WriteConfig(); err != nil {
+		return newError("write to "+s.Util.GetConfigFile(), err)
+	}
+	log.Infoln("Logged out of DCE")
 	return nil
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+
+	"github.com/Optum/dce-cli/configs"
+	observ "github.com/Optum/dce-cli/internal/observation"
+	utl "github.com/Optum/dce-cli/internal/util"
+	"gopkg.in/yaml.v2"
+)
+
+// RedactedToken replaces API tokens in `dce config view`
+const RedactedToken = "REDACTED"
+
+type ConfigService struct {
+	Config      *configs.Root
+	Observation *observ.ObservationContainer
+	Util        *utl.UtilContainer
+}
+
+// ViewConfig writes the config file as YAML, with API tokens redacted
+func (s *ConfigService) ViewConfig() error {
+	config, err := s.redactedConfig()
+	if err != nil {
+		return newError("view config", err)
+	}
+	return writeYAML(config)
+}
+
+// GetConfig writes the value of a setting.
+// Sections and lists are written as YAML, with API tokens redacted.
+func (s *ConfigService) GetConfig(key string) error {
+	val, err := s.Config.ForFile().Get(key)
+	if err != nil {
+		return NewValidationError("%s", err)
+	}
+	if val == nil {
+		return &Error{Kind: NotFoundError, Op: "get config",
+			Err: fmt.Errorf("%s is not set", key)}
+	}
+	switch reflect.ValueOf(val).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		config, err := s.redactedConfig()
+		if err != nil {
+			return newError("get config", err)
+		}
+		if val, err = config.Get(key); err != nil {
+			return newError("get config", err)
+		}
+		return writeYAML(val)
+	}
+	if _, err := Out.Write([]byte(fmt.Sprintf("%v\n", val))); err != nil {
+		return newError("write output", err)
+	}
+	return nil
+}
+
+// redactedConfig returns a copy of the config file settings, with API tokens redacted
+func (s *ConfigService) redactedConfig() (*configs.Root, error) {
+	// Copy the config, so tokens aren't redacted in the original
+	fileYAML, err := yaml.Marshal(s.Config.ForFile())
+	if err != nil {
+		return nil, err
+	}
+	var config configs.Root
+	if err := yaml.Unmarshal(fileYAML, &config); err != nil {
+		return nil, err
+	}
+
+	redact := func(api *configs.API) {
+		if api.Token != nil {
+			redacted := RedactedToken
+			api.Token = &redacted
+		}
+	}
+	redact(&config.API)
+	for _, ctx := range config.Contexts {
+		if ctx != nil {
+			redact(&ctx.API)
+		}
+	}
+	return &config, nil
+}
+
+// SetConfig sets a setting, and writes it to the config file
+func (s *ConfigService) SetConfig(key string, value string) error {
+	return s.updateConfig(key, func(config *configs.Root) error {
+		return config.Set(key, value)
+	})
+}
+
+// UnsetConfig removes a setting from the config file
+func (s *ConfigService) UnsetConfig(key string) error {
+	return s.updateConfig(key, func(config *configs.Root) error {
+		return config.Unset(key)
+	})
+}
+
+// updateConfig applies an update to the settings as they are in the
+// config file, then writes the config file
+func (s *ConfigService) updateConfig(key string, update func(config *configs.Root) error) error {
+	active := s.Config.ActiveContext()
+	*s.Config = *s.Config.ForFile()
+	if err := update(s.Config); err != nil {
+		return NewValidationError("%s", err)
+	}
+	// Make sure the active context still exists
+	if err := s.Config.UseContext(active); err != nil {
+		return NewValidationError("cannot update %s: %s", key, err)
+	}
+
+	if err := s.Util.WriteConfig(); err != nil {
+		return newError("write to "+s.Util.GetConfigFile(), err)
+	}
+	log.Infof("Updated %s in %s", key, s.Util.GetConfigFile())
+	return nil
+}
+
+// ValidateConfig checks the config file, for unknown keys,
+// invalid types and invalid values
+func (s *ConfigService) ValidateConfig() error {
+	configFile := s.Util.GetConfigFile()
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return newError("read "+configFile, err)
+	}
+
+	problems := configs.Validate(data)
+	if len(problems) == 0 {
+		log.Infof("%s is valid", configFile)
+		return nil
+	}
+	for _, problem := range problems {
+		msg := problem.Message
+		if problem.Key != "" {
+			msg = problem.Key + ": " + msg
+		}
+		if problem.Line > 0 {
+			log.Errorf("%s:%d: %s", filepath.Base(configFile), problem.Line, msg)
+		} else {
+			log.Errorf("%s: %s", filepath.Base(configFile), msg)
+		}
+	}
+	return &Error{Kind: ValidationError, Op: "validate config",
+		Err:  fmt.Errorf("found %d problem(s) in %s", len(problems), configFile),
+		Hint: "Fix them with `dce config set` or `dce config unset`, or by editing the config file."}
+}
+
+// writeYAML writes the payload as YAML, regardless of the output format,
+// as the config file is YAML
+func writeYAML(payload interface{}) error {
+	output, err := yaml.Marshal(payload)
+	if err != nil {
+		return newError("format output", err)
+	}
+	if _, err = Out.Write(output); err != nil {
+		return newError("write output", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Optum/dce-cli/configs"
+	observ "github.com/Optum/dce-cli/internal/observation"
+	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+type ContextsService struct {
+	Config      *configs.Root
+	Observation *observ.ObservationContainer
+	Util        *utl.UtilContainer
+}
+
+// ContextSummary describes a context, for `dce context list`
+type ContextSummary struct {
+	Name        string `json:"name"`
+	Current     bool   `json:"current"`
+	APIHost     string `json:"apiHost,omitempty"`
+	APIBasePath string `json:"apiBasePath,omitempty"`
+	Region      string `json:"region,omitempty"`
+}
+
+// ListContexts writes all contexts, including the default context
+func (s *ContextsService) ListContexts() error {
+	config := s.Config.ForFile()
+	current := configs.DefaultContext
+	if config.CurrentContext != nil && *config.CurrentContext != "" {
+		current = *config.CurrentContext
+	}
+
+	summaries := []*ContextSummary{}
+	for _, name := range config.ContextNames() {
+		settings := configs.Context{API: config.API, Region: config.Region}
+		if name != configs.DefaultContext && config.Contexts[name] != nil {
+			settings = *config.Contexts[name]
+		}
+		summaries = append(summaries, &ContextSummary{
+			Name:        name,
+			Current:     name == current,
+			APIHost:     aws.StringValue(settings.API.Host),
+			APIBasePath: aws.StringValue(settings.API.BasePath),
+			Region:      aws.StringValue(settings.Region),
+		})
+	}
+	return writeOutput(summaries)
+}
+
+// UseContext sets the context used by future commands
+func (s *ContextsService) UseContext(name string) error {
+	if err := s.requireContext(name); err != nil {
+		return err
+	}
+	if name == configs.DefaultContext {
+		s.Config.CurrentContext = nil
+	} else {
+		s.Config.CurrentContext = &name
+	}
+	if err := s.Util.WriteConfig(); err != nil {
+		return newError("write to "+s.Util.GetConfigFile(), err)
+	}
+	log.Infof("Switched to context \"%s\"", name)
+	return nil
+}
+
+// AddContext adds a named context
+func (s *ContextsService) AddContext(name string, settings *configs.Context) error {
+	if name == "" {
+		return NewValidationError("context name is required")
+	}
+	if name == configs.DefaultContext {
+		return NewValidationError("\"%s\" is reserved for the top-level settings in the config file", name)
+	}
+	if _, ok := s.Config.Contexts[name]; ok {
+		return &Error{Kind: ConflictError, Op: "add context",
+			Err:  fmt.Errorf("context \"%s\" already exists", name),
+			Hint: "Remove it first with `dce context remove " + name + "`."}
+	}
+
+	if s.Config.Contexts == nil {
+		s.Config.Contexts = map[string]*configs.Context{}
+	}
+	s.Config.Contexts[name] = settings
+	if err := s.Util.WriteConfig(); err != nil {
+		return newError("write to "+s.Util.GetConfigFile(), err)
+	}
+	log.Infof("Added context \"%s\". Run `dce context use %s` to use it.", name, name)
+	return nil
+}
+
+// RemoveContext removes a named context
+func (s *ContextsService) RemoveContext(name string) error {
+	if name == configs.DefaultContext {
+		return NewValidationError("the \"%s\" context cannot be removed", name)
+	}
+	if err := s.requireContext(name); err != nil {
+		return err
+	}
+	if name == s.Config.ActiveContext() {
+		return NewValidationError("cannot remove the context in use. Switch to another context first, with `dce context use`")
+	}
+
+	delete(s.Config.Contexts, name)
+	if s.Config.CurrentContext != nil && *s.Config.CurrentContext == name {
+		s.Config.CurrentContext = nil
+	}
+	if err := s.Util.WriteConfig(); err != nil {
+		return newError("write to "+s.Util.GetConfigFile(), err)
+	}
+	log.Infof("Removed context \"%s\"", name)
+	return nil
+}
+
+// requireContext returns a NotFoundError if the context does not exist
+func (s *ContextsService) requireContext(name string) error {
+	if name == configs.DefaultContext {
+		return nil
+	}
+	if _, ok := s.Config.Contexts[name]; !ok {
+		return &Error{Kind: NotFoundError, Op: "find context",
+			Err:  fmt.Errorf("context \"%s\" not found", name),
+			Hint: "Run `dce context list` to see available contexts."}
+	}
+	return nil
+}
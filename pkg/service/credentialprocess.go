@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	utl "github.com/Optum/dce-cli/internal/util"
+)
+
+// credentialProcessVersion is the version of the credential_process output format
+// See https://docs.aws.amazon.com/cli/latest/topic/config-vars.html#sourcing-credentials-from-external-processes
+const credentialProcessVersion = 1
+
+// Lease credentials are valid for an hour, if the API doesn't say otherwise
+const defaultLeaseCredsDuration = time.Hour
+
+// Cached credentials are refreshed this long before they expire,
+// so AWS tools don't receive credentials which are about to expire
+const credentialRefreshWindow = 5 * time.Minute
+
+// credentialProcessOutput is the JSON format AWS SDKs expect from a `credential_process`
+type credentialProcessOutput struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      string
+}
+
+// CredentialProcess prints credentials for a leased account, in the format
+// expected by the `credential_process` AWS config setting.
+// If no Lease ID is provided, uses the active lease for the requesting user.
+// Credentials are cached until shortly before they expire.
+func (s *LeasesService) CredentialProcess(ctx context.Context, leaseID string) error {
+	cacheFile := s.credentialCacheFile(leaseID)
+	creds, err := readCachedCredentials(cacheFile)
+	if err != nil {
+		log.Debugf("Ignoring credentials cache %s: %s", cacheFile, err)
+	}
+
+	if creds == nil {
+		// AWS SDKs run this command without a terminal,
+		// so fail rather than prompt the user to log in again
+		leaseCreds, err := s.getLeaseCreds(utl.WithoutReauthentication(ctx), leaseID)
+		if err != nil {
+			return err
+		}
+		expiration := time.Now().Add(defaultLeaseCredsDuration)
+		if leaseCreds.ExpiresOn > 0 {
+			expiration = time.Unix(int64(leaseCreds.ExpiresOn), 0)
+		}
+		creds = &credentialProcessOutput{
+			Version:         credentialProcessVersion,
+			AccessKeyID:     leaseCreds.AccessKeyID,
+			SecretAccessKey: leaseCreds.SecretAccessKey,
+			SessionToken:    leaseCreds.SessionToken,
+			Expiration:      expiration.UTC().Format(time.RFC3339),
+		}
+		if err := writeCachedCredentials(cacheFile, creds); err != nil {
+			log.Warnf("Failed to cache credentials to %s: %s", cacheFile, err)
+		}
+	}
+
+	// AWS SDKs parse this output, so it is always JSON,
+	// regardless of the --output flag
+	output, err := json.Marshal(creds)
+	if err != nil {
+		return newError("write output", err)
+	}
+	if _, err := Out.Write(output); err != nil {
+		return newError("write output", err)
+	}
+	return nil
+}
+
+// ConfigureCredentialProcess adds a profile to the AWS CLI config file,
+// which uses `dce leases credential-process` to fetch lease credentials
+func (s *LeasesService) ConfigureCredentialProcess(leaseID string, profile string) error {
+	dceBin, err := os.Executable()
+	if err != nil {
+		return newError("find the dce executable", err)
+	}
+	args := []string{dceBin, "--config", s.Util.GetConfigFile(), "leases", "credential-process"}
+	if leaseID != "" {
+		args = append(args, leaseID)
+	}
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			args[i] = `"` + arg + `"`
+		}
+	}
+
+	settings := map[string]string{
+		"credential_process": strings.Join(args, " "),
+	}
+	if s.Config.Region != nil && *s.Config.Region != "" {
+		settings["region"] = *s.Config.Region
+	}
+	if err := s.Util.SetAWSConfigProfile(profile, settings); err != nil {
+		return newError("write AWS CLI profile", err)
+	}
+	if err := s.recordAWSProfile(profile); err != nil {
+		return err
+	}
+	log.Infof("Configured AWS CLI profile \"%s\" to use lease credentials. "+
+		"Use it with `aws --profile %s` or `export AWS_PROFILE=%s`\n", profile, profile, profile)
+	return nil
+}
+
+// credentialCacheDir is where `dce leases credential-process` caches credentials
+func credentialCacheDir(cacheDir string) string {
+	return filepath.Join(cacheDir, "credentials")
+}
+
+func (s *LeasesService) credentialCacheFile(leaseID string) string {
+	name := "active"
+	if leaseID != "" {
+		name = "lease-" + url.PathEscape(leaseID)
+	}
+	return filepath.Join(credentialCacheDir(s.Util.GetCacheDir()), name+".json")
+}
+
+// readCachedCredentials returns the cached credentials,
+// or nil if there are none, or they are about to expire
+func readCachedCredentials(path string) (*credentialProcessOutput, error) {
+	// #nosec
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds credentialProcessOutput
+	if err := json.Unmarshal(contents, &creds); err != nil {
+		return nil, err
+	}
+	expiration, err := time.Parse(time.RFC3339, creds.Expiration)
+	if err != nil {
+		return nil, err
+	}
+	if time.Until(expiration) < credentialRefreshWindow {
+		return nil, nil
+	}
+	return &creds, nil
+}
+
+// writeCachedCredentials saves credentials to the cache,
+// readable only by the current user
+func writeCachedCredentials(path string, creds *credentialProcessOutput) error {
+	contents, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, 0600)
+}
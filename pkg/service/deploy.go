@@ -64,7 +64,7 @@ type DeployConfig struct {
 // Deploy writes the local `main.tf` file, using the overrides, and then
 // calls Terraform init and apply using configuration directory (`~/.dce`)
 // as the working folder and location of local state.
-func (s *DeployService) Deploy(deployConfig *DeployConfig) error {
+func (s *DeployService) Deploy(ctx context.Context, deployConfig *DeployConfig) error {
 
 	// Initialize the folder structure
 	if err := s.Util.CreateConfigDirTree(deployConfig.Version); err != nil {
@@ -79,7 +79,7 @@ func (s *DeployService) Deploy(deployConfig *DeployConfig) error {
 	}
 
 	// Deploy the DCE terraform module
-	artifactsBucket, err := s.createDceInfra(deployConfig)
+	artifactsBucket, err := s.createDceInfra(ctx, deployConfig)
 	if err != nil {
 		return errors.Wrap(err, "error creating infrastructure")
 	}
@@ -94,8 +94,8 @@ func (s *DeployService) Deploy(deployConfig *DeployConfig) error {
 }
 
 // PostDeploy is intended to run after a successful call to Deploy()
-func (s *DeployService) PostDeploy(deployConfig *DeployConfig) error {
-	ctx := context.WithValue(context.Background(), constants.DeployLogFileKey, deployConfig.DeployLogFile)
+func (s *DeployService) PostDeploy(ctx context.Context, deployConfig *DeployConfig) error {
+	ctx = context.WithValue(ctx, constants.DeployLogFileKey, deployConfig.DeployLogFile)
 
 	apiURL, err := s.Util.GetOutput(ctx, "api_url")
 
@@ -112,12 +112,20 @@ func (s *DeployService) PostDeploy(deployConfig *DeployConfig) error {
 	// if the user wants to save them, update them here otherwise
 	// leave tham alone..
 	if deployConfig.SaveTFOptions {
-		// Update the global config object with our local configuration
-		s.Config.Terraform.TFInitOptions = &deployConfig.TFInitOptions
-		s.Config.Terraform.TFApplyOptions = &deployConfig.TFApplyOptions
-		s.Config.Deploy.Location = &deployConfig.Location
-		s.Config.Deploy.Version = &deployConfig.Version
-		s.Config.Deploy.LogFile = &deployConfig.DeployLogFile
+		// Update the global config object with our local configuration.
+		// Set saves the values, even if they came from flags or env vars.
+		options := map[string]string{
+			"terraform.initOptions":  deployConfig.TFInitOptions,
+			"terraform.applyOptions": deployConfig.TFApplyOptions,
+			"deploy.location":        deployConfig.Location,
+			"deploy.version":         deployConfig.Version,
+			"deploy.logFile":         deployConfig.DeployLogFile,
+		}
+		for key, val := range options {
+			if err := s.Config.Set(key, val); err != nil {
+				return err
+			}
+		}
 	}
 
 	return s.Util.WriteConfig()
@@ -140,14 +148,14 @@ func (s *DeployService) createTFMainFile(deployConfig *DeployConfig) (string, er
 	return fileName, nil
 }
 
-func (s *DeployService) createDceInfra(deployConfig *DeployConfig) (string, error) {
+func (s *DeployService) createDceInfra(ctx context.Context, deployConfig *DeployConfig) (string, error) {
 	_, originDir := s.Util.ChToConfigDir()
 	defer s.Util.Chdir(originDir)
 
 	deployLogFileName := deployConfig.DeployLogFile
 
 	// Setup context used by Terraform service
-	ctx := context.WithValue(context.Background(), constants.DeployLogFileKey, deployLogFileName)
+	ctx = context.WithValue(ctx, constants.DeployLogFileKey, deployLogFileName)
 
 	log.Infoln("Initializing")
 	initopts, _ := util.ParseOptions(&deployConfig.TFInitOptions)
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/go-openapi/runtime"
+)
+
+// ErrorKind classifies errors returned by DCE services,
+// so callers can handle failures without parsing error messages.
+type ErrorKind int
+
+const (
+	// UnknownError is any error which does not fit another kind
+	UnknownError ErrorKind = iota
+	// NotFoundError means the requested resource does not exist
+	NotFoundError
+	// UnauthorizedError means the user is not authenticated,
+	// or does not have permission to perform the operation
+	UnauthorizedError
+	// ConflictError means the request conflicts with the current state of a resource,
+	// eg. requesting a lease for a principal who already has one
+	ConflictError
+	// ValidationError means the request was invalid
+	ValidationError
+	// NetworkError means the DCE API could not be reached
+	NetworkError
+	// TimeoutError means the DCE API did not respond in time
+	TimeoutError
+	// ServerError means the DCE API failed to handle the request
+	ServerError
+	// CanceledError means the operation was cancelled, eg. by Ctrl-C
+	CanceledError
+	// LeaseOverBudgetError means a watched lease has spent its budget
+	LeaseOverBudgetError
+	// LeaseEndedError means a watched lease expired, or was otherwise ended
+	LeaseEndedError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case NotFoundError:
+		return "not found"
+	case UnauthorizedError:
+		return "unauthorized"
+	case ConflictError:
+		return "conflict"
+	case ValidationError:
+		return "validation error"
+	case NetworkError:
+		return "network error"
+	case TimeoutError:
+		return "timeout"
+	case ServerError:
+		return "server error"
+	case CanceledError:
+		return "canceled"
+	case LeaseOverBudgetError:
+		return "lease over budget"
+	case LeaseEndedError:
+		return "lease ended"
+	default:
+		return "unknown error"
+	}
+}
+
+// Error is returned by DCE services
+type Error struct {
+	Kind ErrorKind
+	// Op describes the operation which failed, eg. "get lease"
+	Op string
+	// StatusCode is the HTTP status code returned by the DCE API, if any
+	StatusCode int
+	// Code and Message are decoded from the DCE API error response body, if any
+	Code    string
+	Message string
+	// Hint suggests how the user might fix the error
+	Hint string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	msg := e.detail()
+	if e.Op != "" {
+		msg = fmt.Sprintf("failed to %s: %s", e.Op, msg)
+	}
+	if e.Hint != "" {
+		msg += "\n" + e.Hint
+	}
+	return msg
+}
+
+// detail describes the error returned by the DCE API in plain words,
+// rather than the generated API client error (eg. `[GET /leases/{id}][404] getLeasesIdNotFound`)
+func (e *Error) detail() string {
+	if e.StatusCode == 0 {
+		return e.Err.Error()
+	}
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if msg == "" {
+		msg = "unexpected response"
+	}
+	status := strconv.Itoa(e.StatusCode)
+	if e.Code != "" {
+		status += " " + e.Code
+	}
+	return fmt.Sprintf("%s (HTTP %s)", msg, status)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of a service error,
+// or UnknownError for any other error.
+func KindOf(err error) ErrorKind {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr.Kind
+	}
+	return UnknownError
+}
+
+// CommandExitError is returned when a command run by dce exits
+// with a non-zero code, so that dce can exit with the same code
+type CommandExitError struct {
+	Command string
+	Code    int
+}
+
+func (e *CommandExitError) Error() string {
+	return fmt.Sprintf("%s exited with code %d", e.Command, e.Code)
+}
+
+// NewValidationError returns an error for invalid user input
+func NewValidationError(format string, args ...interface{}) error {
+	return &Error{Kind: ValidationError, Err: fmt.Errorf(format, args...)}
+}
+
+// newError wraps a non-API failure, eg. failing to write output
+func newError(op string, err error) error {
+	return &Error{Kind: UnknownError, Op: op, Err: err}
+}
+
+// Generated API client errors are formatted as `[GET /leases/{id}][404] getLeasesIdNotFound`
+var apiErrorRegex = regexp.MustCompile(`^\[(\w+) ([^\]]*)\]\[(\d{3})\]`)
+
+// apiError wraps an error returned by the DCE API client,
+// and classifies it by the response status code
+func apiError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	svcErr := &Error{Kind: UnknownError, Op: op, Err: err}
+
+	var signingErr *utl.SigningError
+	var netErr net.Error
+	if code, ok := apiStatusCode(err); ok {
+		svcErr.StatusCode = code
+		svcErr.Kind = kindForStatus(code)
+		svcErr.Code, svcErr.Message = decodeErrorBody(err)
+	} else if errors.As(err, &signingErr) {
+		svcErr.Kind = UnauthorizedError
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		svcErr.Kind = TimeoutError
+	} else if errors.Is(err, context.Canceled) {
+		svcErr.Kind = CanceledError
+	} else if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			svcErr.Kind = TimeoutError
+		} else {
+			svcErr.Kind = NetworkError
+		}
+	}
+
+	svcErr.Hint = hintFor(svcErr.Kind, op)
+	return svcErr
+}
+
+// hintFor suggests a remedy for a failed API operation
+func hintFor(kind ErrorKind, op string) string {
+	switch kind {
+	case UnauthorizedError:
+		return fmt.Sprintf("Run `dce auth` to log in again, and check that you have permission to %s.", op)
+	case NotFoundError:
+		switch {
+		case strings.Contains(op, "lease"):
+			return "Check that the lease ID is correct. Run `dce leases list` to see leases."
+		case strings.Contains(op, "account"):
+			return "Check that the account ID is correct. Run `dce accounts list` to see accounts."
+		}
+	case ConflictError:
+		switch {
+		case strings.Contains(op, "lease"):
+			return "The principal may already have an active lease. Run `dce leases list` to see leases."
+		case strings.Contains(op, "account"):
+			return "The account may already be added to DCE, or may be leased."
+		}
+	case NetworkError:
+		return "Check your network connection, and that the API host in your DCE config is correct."
+	case TimeoutError:
+		return "The DCE API did not respond in time. Try again later, or increase the timeout with --timeout."
+	case ServerError:
+		return "The DCE API failed to handle the request. Try again later, or contact your DCE administrator."
+	}
+	return ""
+}
+
+// apiStatusCode returns the HTTP status code of an error
+// returned by the generated API client
+func apiStatusCode(err error) (int, bool) {
+	var rtErr *runtime.APIError
+	if errors.As(err, &rtErr) {
+		return rtErr.Code, true
+	}
+	if match := apiErrorRegex.FindStringSubmatch(err.Error()); match != nil {
+		code, convErr := strconv.Atoi(match[3])
+		return code, convErr == nil
+	}
+	return 0, false
+}
+
+// dceErrorBody is the error response body returned by the DCE API,
+// eg. `{"error": {"code": "NotFoundError", "message": "lease not found"}}`
+// API Gateway errors only include a message, eg. `{"message": "Forbidden"}`
+type dceErrorBody struct {
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Message string `json:"message"`
+}
+
+// decodeErrorBody returns the DCE error code and message from the response
+// of a *runtime.APIError, eg. "NotFoundError" and `lease "123" not found`
+func decodeErrorBody(err error) (code string, message string) {
+	var rtErr *runtime.APIError
+	if !errors.As(err, &rtErr) {
+		return "", ""
+	}
+	res, ok := rtErr.Response.(runtime.ClientResponse)
+	if !ok || res.Body() == nil {
+		return "", ""
+	}
+	// The generated client has already closed the response,
+	// so only a body read by utl.ErrorResponseTransport can be decoded
+	body, readErr := ioutil.ReadAll(res.Body())
+	if readErr != nil {
+		return "", ""
+	}
+	var errBody dceErrorBody
+	if jsonErr := json.Unmarshal(body, &errBody); jsonErr != nil {
+		return "", ""
+	}
+	if errBody.Error != nil {
+		return errBody.Error.Code, strings.TrimSpace(errBody.Error.Message)
+	}
+	return "", strings.TrimSpace(errBody.Message)
+}
+
+func kindForStatus(code int) ErrorKind {
+	switch {
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return ValidationError
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return UnauthorizedError
+	case code == http.StatusNotFound:
+		return NotFoundError
+	case code == http.StatusConflict:
+		return ConflictError
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return TimeoutError
+	case code >= 500:
+		return ServerError
+	default:
+		return UnknownError
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// forwardedSignals are passed on to commands run by `dce leases exec`
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// leaseCredsEnvVars are removed from the environment of commands run
+// with lease credentials, so they can't override lease credentials
+var leaseCredsEnvVars = []string{
+	"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_SECURITY_TOKEN",
+	"AWS_CREDENTIAL_EXPIRATION", "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION",
+	"AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_AUTHORIZATION_TOKEN",
+}
+
+// Exec runs a command with lease credentials set in its environment.
+// If no lease ID is provided, uses the active lease for the requesting user.
+// Signals and stdin are forwarded to the command, and a CommandExitError
+// is returned if the command exits with a non-zero code.
+func (s *LeasesService) Exec(ctx context.Context, leaseID string, command []string) error {
+	if len(command) == 0 {
+		return NewValidationError("a command to run is required")
+	}
+	creds, err := s.getLeaseCreds(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Env = s.leaseCredsEnv(os.Environ(), creds)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	return runForwardingSignals(child)
+}
+
+// leaseCredsEnv returns a copy of env, with lease credentials
+// and the configured region set
+func (s *LeasesService) leaseCredsEnv(env []string, creds *leaseCreds) []string {
+	vars := []string{
+		"AWS_ACCESS_KEY_ID=" + creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN=" + creds.SessionToken,
+	}
+	if creds.ExpiresOn > 0 {
+		expiration := time.Unix(int64(creds.ExpiresOn), 0).UTC().Format(time.RFC3339)
+		vars = append(vars, "AWS_CREDENTIAL_EXPIRATION="+expiration)
+	}
+	return s.leaseEnv(env, vars...)
+}
+
+// leaseEnv returns a copy of env, without any AWS credentials or profile,
+// and with the configured region and the given vars set
+func (s *LeasesService) leaseEnv(env []string, vars ...string) []string {
+	leaseVars := []string{}
+	for _, v := range env {
+		name := strings.SplitN(v, "=", 2)[0]
+		keep := true
+		for _, credsVar := range leaseCredsEnvVars {
+			if strings.EqualFold(name, credsVar) {
+				keep = false
+			}
+		}
+		if keep {
+			leaseVars = append(leaseVars, v)
+		}
+	}
+
+	if s.Config.Region != nil && *s.Config.Region != "" {
+		leaseVars = append(leaseVars, "AWS_REGION="+*s.Config.Region, "AWS_DEFAULT_REGION="+*s.Config.Region)
+	}
+	return append(leaseVars, vars...)
+}
+
+// runForwardingSignals runs a command until it exits,
+// forwarding signals received by dce to it
+func runForwardingSignals(child *exec.Cmd) error {
+	name := child.Args[0]
+	if err := child.Start(); err != nil {
+		return newError("run "+name, err)
+	}
+
+	// Stop cancelling dce on Ctrl-C, so the command decides
+	// whether to exit when it is interrupted
+	signal.Reset(forwardedSignals...)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	go func() {
+		for sig := range signals {
+			if err := child.Process.Signal(sig); err != nil {
+				log.Debugf("Failed to forward %s to %s: %s", sig, name, err)
+			}
+		}
+	}()
+
+	err := child.Wait()
+	signal.Stop(signals)
+	close(signals)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &CommandExitError{Command: name, Code: exitStatus(exitErr.ProcessState)}
+	}
+	if err != nil {
+		return newError("run "+name, err)
+	}
+	return nil
+}
+
+// exitStatus returns the exit code of a process,
+// or 128 + the signal number if it was killed by a signal,
+// following the shell convention
+func exitStatus(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
@@ -13,7 +13,7 @@ type InitService struct {
 	Util        *utl.UtilContainer
 }
 
-func (s *InitService) InitializeDCE() {
+func (s *InitService) InitializeDCE() error {
 	// Set default region
 	if s.Config.Region == nil {
 		s.Config.Region = aws.String("us-east-1")
@@ -25,11 +25,11 @@ func (s *InitService) InitializeDCE() {
 	// Write the config to the config file
 	err := s.Util.WriteConfig()
 	if err != nil {
-		log.Fatalf("Failed to write YAML config to %s: %s",
-			s.Util.GetConfigFile(), err)
+		return newError("write YAML config to "+s.Util.GetConfigFile(), err)
 	}
 
 	log.Infoln("Config file created at: " + s.Util.GetConfigFile())
+	return nil
 }
 
 func (s *InitService) promptUserForConfig(config *configs.Root) {
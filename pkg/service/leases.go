@@ -1,8 +1,9 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"net/url"
 	"path/filepath"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/Optum/dce-cli/internal/constants"
 	observ "github.com/Optum/dce-cli/internal/observation"
 	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/Optum/dce-cli/models"
 )
 
 type LeasesService struct {
@@ -19,7 +21,23 @@ type LeasesService struct {
 	Util        *utl.UtilContainer
 }
 
-func (s *LeasesService) CreateLease(principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string) {
+// CreateLease creates a lease, and writes it to the output.
+// If opts.Wait or opts.Login are set, waits until the lease is active,
+// and writes the active lease instead.
+func (s *LeasesService) CreateLease(ctx context.Context, principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string, opts *LeaseCreateOptions) error {
+	lease, err := s.createLease(ctx, principalID, budgetAmount, budgetCurrency, email, expiresOn, opts)
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(lease); err != nil {
+		return err
+	}
+	return s.loginToNewLease(ctx, lease.ID, opts)
+}
+
+// createLease creates a lease, and waits for it to become active
+// if opts.Wait or opts.Login are set
+func (s *LeasesService) createLease(ctx context.Context, principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string, opts *LeaseCreateOptions) (*models.Lease, error) {
 	postBody := operations.PostLeasesBody{
 		PrincipalID:              &principalID,
 		BudgetAmount:             &budgetAmount,
@@ -28,8 +46,10 @@ func (s *LeasesService) CreateLease(principalID string, budgetAmount float64, bu
 	}
 
 	expiry, err := s.Util.ExpandEpochTime(expiresOn)
-
-	if err == nil && expiry > 0 {
+	if err != nil {
+		return nil, NewValidationError("invalid expiry \"%s\": %s", expiresOn, err)
+	}
+	if expiry > 0 {
 		expiryf := float64(expiry)
 		postBody.ExpiresOn = expiryf
 	}
@@ -37,29 +57,41 @@ func (s *LeasesService) CreateLease(principalID string, budgetAmount float64, bu
 	params := &operations.PostLeasesParams{
 		Lease: postBody,
 	}
-	params.SetTimeout(5 * time.Second)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	params.SetContext(reqCtx)
 	res, err := ApiClient.PostLeases(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
+		return nil, apiError("create lease", err)
 	}
-	jsonPayload, err := json.MarshalIndent(res.GetPayload(), "", "\t")
-	if err != nil {
-		log.Fatalln("err: ", err)
+	lease := models.Lease(*res.GetPayload())
+	if opts == nil || !(opts.Wait || opts.Login != nil) {
+		return &lease, nil
 	}
+	return s.waitForLease(ctx, &lease, opts.WaitTimeout)
+}
 
-	if _, err := Out.Write(jsonPayload); err != nil {
-		log.Fatalln("err: ", err)
-
+// loginToNewLease logs in to a new lease, if opts.Login is set
+func (s *LeasesService) loginToNewLease(ctx context.Context, leaseID string, opts *LeaseCreateOptions) error {
+	if opts == nil || opts.Login == nil {
+		return nil
+	}
+	creds, err := s.getLeaseCreds(ctx, leaseID)
+	if err != nil {
+		return err
 	}
+	return s.loginWithCreds(leaseID, creds, opts.Login)
 }
 
-func (s *LeasesService) EndLease(leaseID, accountID, principalID string) {
+func (s *LeasesService) EndLease(ctx context.Context, leaseID, accountID, principalID string) error {
 	var err error = nil
 	if leaseID != "" {
 		params := &operations.DeleteLeasesIDParams{
 			ID: leaseID,
 		}
-		params.SetTimeout(5 * time.Second)
+		reqCtx, cancel := requestContext(ctx)
+		defer cancel()
+		params.SetContext(reqCtx)
 		_, err = ApiClient.DeleteLeasesID(params, nil)
 	} else if accountID != "" && principalID != "" {
 		params := &operations.DeleteLeasesParams{
@@ -68,59 +100,82 @@ func (s *LeasesService) EndLease(leaseID, accountID, principalID string) {
 				PrincipalID: &principalID,
 			},
 		}
-		params.SetTimeout(5 * time.Second)
+		reqCtx, cancel := requestContext(ctx)
+		defer cancel()
+		params.SetContext(reqCtx)
 		_, err = ApiClient.DeleteLeases(params, nil)
+	} else {
+		return NewValidationError("a lease ID, or both an account ID and principal ID are required to end a lease")
 	}
 
 	if err != nil {
-		log.Fatalln("err: ", err)
+		return apiError("end lease", err)
 	}
 
 	if _, err := Out.Write([]byte("Lease ended")); err != nil {
-		log.Fatalln("err: ", err)
-
+		return newError("write output", err)
 	}
+	return nil
 }
 
-func (s *LeasesService) GetLease(leaseID string) {
+func (s *LeasesService) GetLease(ctx context.Context, leaseID string) error {
 	params := &operations.GetLeasesIDParams{
 		ID: leaseID,
 	}
-	params.SetTimeout(5 * time.Second)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	params.SetContext(reqCtx)
 	res, err := ApiClient.GetLeasesID(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
-	}
-	jsonPayload, err := json.MarshalIndent(res.GetPayload(), "", "\t")
-	if err != nil {
-		log.Fatalln("err: ", err)
-	}
-	if _, err := Out.Write(jsonPayload); err != nil {
-		log.Fatalln("err: ", err)
+		return apiError("get lease", err)
 	}
+	lease := models.Lease(*res.GetPayload())
+	return writeOutput(&lease)
 }
 
-func (s *LeasesService) ListLeases(acctID, principalID, nextAcctID, nextPrincipalID, leaseStatus string, pagLimit int64) {
+func (s *LeasesService) ListLeases(ctx context.Context, opts *LeaseListOptions) error {
 	params := &operations.GetLeasesParams{
-		AccountID:       &acctID,
-		Limit:           &pagLimit,
-		NextAccountID:   &nextAcctID,
-		NextPrincipalID: &nextPrincipalID,
-		PrincipalID:     &principalID,
-		Status:          &leaseStatus,
-	}
-	params.SetTimeout(5 * time.Second)
-	res, err := ApiClient.GetLeases(params, nil)
-	if err != nil {
-		log.Fatalln("err: ", err)
+		AccountID:       &opts.AccountID,
+		Limit:           &opts.Limit,
+		NextAccountID:   &opts.NextAccountID,
+		NextPrincipalID: &opts.NextPrincipalID,
+		PrincipalID:     &opts.PrincipalID,
+		Status:          &opts.Status,
 	}
-	jsonPayload, err := json.MarshalIndent(res.GetPayload(), "", "\t")
+
+	leases := []*models.Lease{}
+	more, err := paginate(opts.All, opts.MaxItems, func(next url.Values) (string, int64, error) {
+		if next != nil {
+			params.NextAccountID = pageParam(next, "nextAccountId")
+			params.NextPrincipalID = pageParam(next, "nextPrincipalId")
+		}
+		reqCtx, cancel := requestContext(ctx)
+		defer cancel()
+		params.SetContext(reqCtx)
+		res, err := ApiClient.GetLeases(params, nil)
+		if err != nil {
+			return "", 0, apiError("list leases", err)
+		}
+		for _, item := range res.GetPayload() {
+			lease := models.Lease(*item)
+			leases = append(leases, &lease)
+		}
+		return res.Link, int64(len(leases)), nil
+	})
 	if err != nil {
-		log.Fatalln("err: ", err)
+		return err
 	}
-	if _, err := Out.Write(jsonPayload); err != nil {
-		log.Fatalln("err: ", err)
+
+	if opts.MaxItems > 0 && int64(len(leases)) > opts.MaxItems {
+		leases = leases[:opts.MaxItems]
+	}
+	if more != nil {
+		log.Infof("More leases are available. Use --all to list them, "+
+			"or use --next-account-id %s --next-principal-id %s to view the next page.\n",
+			more.Get("nextAccountId"), more.Get("nextPrincipalId"))
 	}
+
+	return writeOutput(leases)
 }
 
 type leaseCreds struct {
@@ -131,48 +186,75 @@ type leaseCreds struct {
 	SessionToken    string  `json:"sessionToken,omitempty"`
 }
 
-func (s *LeasesService) Login(opts *LeaseLoginOptions) {
-	log.Debugln("Requesting leased account credentials")
-
-	params := &operations.PostLeasesAuthParams{}
-	params.SetTimeout(20 * time.Second)
-	res, err := ApiClient.PostLeasesAuth(params, nil)
-
+func (s *LeasesService) Login(ctx context.Context, opts *LeaseLoginOptions) error {
+	creds, err := s.getLeaseCreds(ctx, "")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	return s.loginWithCreds("", creds, opts)
+}
 
-	responsePayload := res.GetPayload()
-
-	creds := leaseCreds(*responsePayload)
-	s.loginWithCreds(&creds, opts)
+func (s *LeasesService) LoginByID(ctx context.Context, leaseID string, opts *LeaseLoginOptions) error {
+	creds, err := s.getLeaseCreds(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	return s.loginWithCreds(leaseID, creds, opts)
 }
 
-func (s *LeasesService) LoginByID(leaseID string, opts *LeaseLoginOptions) {
+// getLeaseCreds requests credentials for the leased account.
+// If no lease ID is provided, uses the active lease for the requesting user.
+func (s *LeasesService) getLeaseCreds(ctx context.Context, leaseID string) (*leaseCreds, error) {
 	log.Debugln("Requesting leased account credentials")
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+
+	if leaseID == "" {
+		params := &operations.PostLeasesAuthParams{}
+		params.SetContext(reqCtx)
+		res, err := ApiClient.PostLeasesAuth(params, nil)
+		if err != nil {
+			return nil, apiError("get leased account credentials", err)
+		}
+		creds := leaseCreds(*res.GetPayload())
+		return &creds, nil
+	}
+
 	params := &operations.PostLeasesIDAuthParams{
 		ID: leaseID,
 	}
-	params.SetTimeout(20 * time.Second)
+	params.SetContext(reqCtx)
 	res, err := ApiClient.PostLeasesIDAuth(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
+		return nil, apiError("get leased account credentials", err)
 	}
-
-	responsePayload := res.GetPayload()
-
-	creds := leaseCreds(*responsePayload)
-	s.loginWithCreds(&creds, opts)
+	creds := leaseCreds(*res.GetPayload())
+	return &creds, nil
 }
 
-func (s *LeasesService) loginWithCreds(leaseCreds *leaseCreds, opts *LeaseLoginOptions) {
+func (s *LeasesService) loginWithCreds(leaseID string, leaseCreds *leaseCreds, opts *LeaseLoginOptions) error {
 	if !(opts.OpenBrowser || opts.PrintCreds) {
 		credsPath := filepath.Join(".aws", "credentials")
-		log.Infoln("Adding credentials to " + credsPath + " using AWS CLI")
-		s.Util.ConfigureAWSCLICredentials(leaseCreds.AccessKeyID,
-			leaseCreds.SecretAccessKey,
-			leaseCreds.SessionToken,
-			opts.CliProfile)
+		log.Infoln("Adding credentials to " + credsPath)
+		cliCreds := &utl.AWSCLICredentials{
+			Profile:         opts.CliProfile,
+			AccessKeyID:     leaseCreds.AccessKeyID,
+			SecretAccessKey: leaseCreds.SecretAccessKey,
+			SessionToken:    leaseCreds.SessionToken,
+			LeaseID:         leaseID,
+		}
+		if leaseCreds.ExpiresOn > 0 {
+			cliCreds.Expiration = time.Unix(int64(leaseCreds.ExpiresOn), 0)
+		}
+		if s.Config.Region != nil {
+			cliCreds.Region = *s.Config.Region
+		}
+		if err := s.Util.ConfigureAWSCLICredentials(cliCreds); err != nil {
+			return newError("write AWS CLI credentials", err)
+		}
+		if err := s.recordAWSProfile(opts.CliProfile); err != nil {
+			return err
+		}
 
 	} else if opts.CliProfile != "default" {
 		log.Infoln("Setting --profile has no effect when used with other flags.\n")
@@ -189,7 +271,23 @@ func (s *LeasesService) loginWithCreds(leaseCreds *leaseCreds, opts *LeaseLoginO
 			leaseCreds.SecretAccessKey,
 			leaseCreds.SessionToken)
 		if _, err := Out.Write([]byte(creds)); err != nil {
-			log.Fatalln("err: ", err)
+			return newError("write output", err)
+		}
+	}
+	return nil
+}
+
+// recordAWSProfile saves an AWS CLI profile written by `dce leases`
+// to the DCE config, so that `dce auth logout` can remove it
+func (s *LeasesService) recordAWSProfile(profile string) error {
+	for _, existing := range s.Config.AWSProfiles {
+		if existing == profile {
+			return nil
 		}
 	}
+	s.Config.AWSProfiles = append(s.Config.AWSProfiles, profile)
+	if err := s.Util.WriteConfig(); err != nil {
+		return newError("write to "+s.Util.GetConfigFile(), err)
+	}
+	return nil
 }
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Optum/dce-cli/models"
+	"gopkg.in/yaml.v2"
+)
+
+// LeaseSpec describes a lease to create. Lease spec files contain one
+// or more lease specs, with the same fields as the `POST /leases` request.
+type LeaseSpec struct {
+	PrincipalID              string   `yaml:"principalId"`
+	BudgetAmount             float64  `yaml:"budgetAmount"`
+	BudgetCurrency           string   `yaml:"budgetCurrency"`
+	BudgetNotificationEmails []string `yaml:"budgetNotificationEmails"`
+	// ExpiresOn is a UNIX epoch time, or a time relative to now, eg. "7d" or "8h"
+	ExpiresOn string `yaml:"expiresOn"`
+}
+
+// Defaults for lease specs, which match the defaults of `dce leases create`
+const (
+	DefaultLeaseBudgetCurrency = "USD"
+	DefaultLeaseExpiresOn      = "7d"
+)
+
+// leaseSpecDoc is a lease spec, as written in a lease spec file.
+// Values are decoded as strings, so that env vars may be used for any value.
+type leaseSpecDoc struct {
+	PrincipalID              string   `yaml:"principalId"`
+	BudgetAmount             string   `yaml:"budgetAmount"`
+	BudgetCurrency           string   `yaml:"budgetCurrency"`
+	BudgetNotificationEmails []string `yaml:"budgetNotificationEmails"`
+	ExpiresOn                string   `yaml:"expiresOn"`
+}
+
+// ParseLeaseSpecs parses lease specs from YAML or JSON. Each YAML document,
+// separated by `---`, may be a single lease spec, or a list of lease specs.
+// Env vars are substituted in values for `$VAR` and `${VAR}`, or `${VAR:-default}`
+// to use a default when VAR is not set. Use `$$` for a literal `$`.
+func ParseLeaseSpecs(data []byte, lookupEnv func(key string) (string, bool)) ([]*LeaseSpec, error) {
+	// Find out which documents are lists, then decode them strictly,
+	// so that unknown fields and invalid types are reported with their line
+	isList := []bool{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		_, ok := doc.([]interface{})
+		isList = append(isList, ok)
+	}
+
+	// Env vars are substituted after parsing, so their values
+	// can't change the structure of the document
+	vars := &varExpander{lookupEnv: lookupEnv, missing: map[string]bool{}}
+	specs := []*LeaseSpec{}
+	decoder = yaml.NewDecoder(bytes.NewReader(data))
+	decoder.SetStrict(true)
+	for i := range isList {
+		docs := []*leaseSpecDoc{}
+		var err error
+		if isList[i] {
+			err = decoder.Decode(&docs)
+		} else {
+			var doc *leaseSpecDoc
+			err = decoder.Decode(&doc)
+			docs = append(docs, doc)
+		}
+		if err != nil {
+			msg := strings.Replace(err.Error(), " in type service.leaseSpecDoc", "", -1)
+			return nil, fmt.Errorf("document %d: %s", i+1, msg)
+		}
+		for _, doc := range docs {
+			// Skip empty documents, eg. after a trailing `---`
+			if doc == nil {
+				continue
+			}
+			spec, err := doc.expand(vars)
+			if err != nil {
+				return nil, fmt.Errorf("document %d: %s", i+1, err)
+			}
+			specs = append(specs, spec)
+		}
+	}
+	if err := vars.err(); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// expand substitutes env vars in the values of a lease spec
+func (doc *leaseSpecDoc) expand(vars *varExpander) (*LeaseSpec, error) {
+	spec := &LeaseSpec{
+		PrincipalID:    vars.expand(doc.PrincipalID),
+		BudgetCurrency: vars.expand(doc.BudgetCurrency),
+		ExpiresOn:      vars.expand(doc.ExpiresOn),
+	}
+	for _, email := range doc.BudgetNotificationEmails {
+		spec.BudgetNotificationEmails = append(spec.BudgetNotificationEmails, vars.expand(email))
+	}
+	if amount := vars.expand(doc.BudgetAmount); amount != "" {
+		val, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid budgetAmount \"%s\": must be a number", amount)
+		}
+		spec.BudgetAmount = val
+	}
+	return spec, nil
+}
+
+// varExpander substitutes env vars in lease spec values,
+// and records any which are not set
+type varExpander struct {
+	lookupEnv func(key string) (string, bool)
+	missing   map[string]bool
+}
+
+func (e *varExpander) expand(val string) string {
+	return os.Expand(val, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		def, hasDefault := "", false
+		if i := strings.Index(name, ":-"); i >= 0 {
+			name, def, hasDefault = name[:i], name[i+2:], true
+		}
+		if val, ok := e.lookupEnv(name); ok && val != "" {
+			return val
+		}
+		if !hasDefault {
+			e.missing[name] = true
+		}
+		return def
+	})
+}
+
+// err lists the env vars which are not set
+func (e *varExpander) err() error {
+	if len(e.missing) == 0 {
+		return nil
+	}
+	names := []string{}
+	for name := range e.missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("env vars are not set: %s. Use ${VAR:-default} to set a default", strings.Join(names, ", "))
+}
+
+// withDefaults returns a copy of the spec, with the fields set in
+// overrides replacing its own, and defaults for fields which are not set
+func (spec LeaseSpec) withDefaults(overrides *LeaseSpec) *LeaseSpec {
+	if overrides != nil {
+		if overrides.PrincipalID != "" {
+			spec.PrincipalID = overrides.PrincipalID
+		}
+		if overrides.BudgetAmount != 0 {
+			spec.BudgetAmount = overrides.BudgetAmount
+		}
+		if overrides.BudgetCurrency != "" {
+			spec.BudgetCurrency = overrides.BudgetCurrency
+		}
+		if len(overrides.BudgetNotificationEmails) > 0 {
+			spec.BudgetNotificationEmails = overrides.BudgetNotificationEmails
+		}
+		if overrides.ExpiresOn != "" {
+			spec.ExpiresOn = overrides.ExpiresOn
+		}
+	}
+	if spec.BudgetCurrency == "" {
+		spec.BudgetCurrency = DefaultLeaseBudgetCurrency
+	}
+	if spec.ExpiresOn == "" {
+		spec.ExpiresOn = DefaultLeaseExpiresOn
+	}
+	return &spec
+}
+
+// validateLeaseSpec checks that a lease spec has the fields required to create a lease
+func (s *LeasesService) validateLeaseSpec(spec *LeaseSpec) []string {
+	problems := []string{}
+	if spec.PrincipalID == "" {
+		problems = append(problems, "principalId is required")
+	}
+	if spec.BudgetAmount <= 0 {
+		problems = append(problems, "budgetAmount must be greater than 0")
+	}
+	if len(spec.BudgetNotificationEmails) == 0 {
+		problems = append(problems, "budgetNotificationEmails is required")
+	}
+	for _, email := range spec.BudgetNotificationEmails {
+		if _, err := mail.ParseAddress(email); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid budgetNotificationEmails address \"%s\"", email))
+		}
+	}
+	if _, err := s.Util.ExpandEpochTime(spec.ExpiresOn); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid expiresOn \"%s\": %s", spec.ExpiresOn, err))
+	}
+	return problems
+}
+
+// CreateLeasesFromFile creates the leases described by a lease spec file,
+// or stdin if the file is "-". Fields set in overrides replace those in the file.
+// All leases are validated before any are created.
+func (s *LeasesService) CreateLeasesFromFile(ctx context.Context, file string, overrides *LeaseSpec, opts *LeaseCreateOptions) error {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return NewValidationError("failed to read lease spec file: %s", err)
+	}
+
+	parsed, err := ParseLeaseSpecs(data, os.LookupEnv)
+	if err != nil {
+		return NewValidationError("invalid lease spec file %s: %s", file, err)
+	}
+	if len(parsed) == 0 {
+		return NewValidationError("no leases found in %s", file)
+	}
+	if opts != nil && opts.Login != nil && len(parsed) > 1 {
+		return NewValidationError("--login may only be used with a single lease, but %s has %d", file, len(parsed))
+	}
+
+	specs := []*LeaseSpec{}
+	invalid := false
+	for i, spec := range parsed {
+		spec = spec.withDefaults(overrides)
+		for _, problem := range s.validateLeaseSpec(spec) {
+			log.Errorf("%s: lease %d: %s", file, i+1, problem)
+			invalid = true
+		}
+		specs = append(specs, spec)
+	}
+	if invalid {
+		return NewValidationError("invalid lease spec file %s", file)
+	}
+
+	// The created leases are written as one list
+	leases := []*models.Lease{}
+	for i, spec := range specs {
+		lease, err := s.createLease(ctx, spec.PrincipalID, spec.BudgetAmount, spec.BudgetCurrency,
+			spec.BudgetNotificationEmails, spec.ExpiresOn, opts)
+		if err != nil {
+			if i > 0 {
+				log.Warnf("Created %d of %d leases in %s", i, len(specs), file)
+				if err := writeOutput(leases); err != nil {
+					log.Warnf("Failed to write the created leases: %s", err)
+				}
+			}
+			return err
+		}
+		leases = append(leases, lease)
+	}
+	if err := writeOutput(leases); err != nil {
+		return err
+	}
+	// --login may only be used with a single lease
+	return s.loginToNewLease(ctx, leases[0].ID, opts)
+}
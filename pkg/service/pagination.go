@@ -0,0 +1,71 @@
+package service
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var linkRegex = regexp.MustCompile(`<([^>]*)>\s*((?:;\s*[^;,]+)*)`)
+var linkRelRegex = regexp.MustCompile(`rel="?([^";]+)"?`)
+
+// nextPage parses the `Link` response header returned by paginated
+// DCE API endpoints, and returns the query params for the next page.
+//
+// eg. `<https://dce.example.com/api/leases?limit=25&nextAccountId=123>; rel="next"`
+func nextPage(link string) (url.Values, bool) {
+	for _, match := range linkRegex.FindAllStringSubmatch(link, -1) {
+		rel := linkRelRegex.FindStringSubmatch(match[2])
+		if rel == nil || !strings.EqualFold(strings.TrimSpace(rel[1]), "next") {
+			continue
+		}
+		nextURL, err := url.Parse(match[1])
+		if err != nil {
+			return nil, false
+		}
+		return nextURL.Query(), true
+	}
+	return nil, false
+}
+
+// paginate fetches pages of results from a paginated DCE API endpoint.
+//
+// fetchPage is called with the query params of the page to fetch
+// (nil for the first page), and returns the response `Link` header,
+// and the total number of items fetched so far.
+//
+// Pagination links are followed if `all` is set, or until `maxItems`
+// have been fetched. Otherwise only the first page is fetched.
+// If more pages are available, the query params of the next page are returned.
+func paginate(all bool, maxItems int64, fetchPage func(next url.Values) (string, int64, error)) (url.Values, error) {
+	var current url.Values
+	for {
+		link, count, err := fetchPage(current)
+		if err != nil {
+			return nil, err
+		}
+
+		next, ok := nextPage(link)
+		if !ok || (maxItems > 0 && count >= maxItems) {
+			return nil, nil
+		}
+		if !all && maxItems <= 0 {
+			return next, nil
+		}
+		// Guard against the API returning the same page twice
+		if current != nil && next.Encode() == current.Encode() {
+			return nil, nil
+		}
+		current = next
+	}
+}
+
+// pageParam returns a pointer to a query param from the next page URL,
+// or nil if the param is not set
+func pageParam(query url.Values, key string) *string {
+	val := query.Get(key)
+	if val == "" {
+		return nil
+	}
+	return &val
+}
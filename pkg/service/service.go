@@ -1,7 +1,11 @@
 package service
 
 import (
+	"context"
+	"time"
+
 	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/constants"
 	observ "github.com/Optum/dce-cli/internal/observation"
 	utl "github.com/Optum/dce-cli/internal/util"
 )
@@ -17,11 +21,14 @@ type ServiceContainer struct {
 	Initer
 	Authenticater
 	Usager
+	Contexter
+	Configer
 }
 
 var log observ.Logger
 var ApiClient utl.APIer
 var Out observ.OutputWriter
+var Formatter observ.Formatter
 
 // New returns a new ServiceContainer given config
 func New(config *configs.Root, observation *observ.ObservationContainer, util *utl.UtilContainer) *ServiceContainer {
@@ -29,6 +36,10 @@ func New(config *configs.Root, observation *observ.ObservationContainer, util *u
 	log = observation.Logger
 	ApiClient = util.APIer
 	Out = observation.OutputWriter
+	Formatter = observation.Formatter
+	if Formatter == nil {
+		Formatter = &observ.JSONFormatter{}
+	}
 
 	serviceContainer := ServiceContainer{
 		Config:        config,
@@ -40,11 +51,46 @@ func New(config *configs.Root, observation *observ.ObservationContainer, util *u
 		Initer:        &InitService{Config: config, Util: util},
 		Authenticater: &AuthService{Config: config, Util: util},
 		Usager:        &UsageService{Config: config, Util: util},
+		Contexter:     &ContextsService{Config: config, Util: util},
+		Configer:      &ConfigService{Config: config, Util: util},
 	}
 
 	return &serviceContainer
 }
 
+// writeOutput renders the payload using the configured output format,
+// and writes it to the output writer
+func writeOutput(payload interface{}) error {
+	output, err := Formatter.Format(payload)
+	if err != nil {
+		return newError("format output", err)
+	}
+	if _, err = Out.Write(output); err != nil {
+		return newError("write output", err)
+	}
+	return nil
+}
+
+// DefaultAPITimeout is the timeout for each DCE API request,
+// unless another is set with WithAPITimeout
+const DefaultAPITimeout = 5 * time.Second
+
+// WithAPITimeout returns a copy of the context,
+// which sets the timeout for each DCE API request made with it
+func WithAPITimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, constants.APITimeoutKey, timeout)
+}
+
+// requestContext returns a context for a single DCE API request,
+// which times out after the API timeout set on the parent context
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout, ok := ctx.Value(constants.APITimeoutKey).(time.Duration)
+	if !ok || timeout <= 0 {
+		timeout = DefaultAPITimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 type DeployOverrides struct {
 	AWSRegion                         string
 	GlobalTags                        []string
@@ -59,19 +105,33 @@ type DeployOverrides struct {
 	DCEModulePath string
 }
 type Deployer interface {
-	Deploy(input *DeployConfig) error
-	PostDeploy(input *DeployConfig) error
+	Deploy(ctx context.Context, input *DeployConfig) error
+	PostDeploy(ctx context.Context, input *DeployConfig) error
 }
 
 type Usager interface {
-	GetUsage(startDate, endDate float64)
+	GetUsage(ctx context.Context, startDate, endDate float64) error
+}
+
+type AccountListOptions struct {
+	Status              string
+	AdminRoleArn        string
+	PrincipalRoleArn    string
+	PrincipalPolicyHash string
+	NextID              string
+	// Limit is the number of accounts to request per page
+	Limit int64
+	// All follows pagination links, until all accounts have been fetched
+	All bool
+	// MaxItems caps the number of accounts returned. Follows pagination links until reached.
+	MaxItems int64
 }
 
 type Accounter interface {
-	AddAccount(accountID, adminRoleARN string)
-	RemoveAccount(accountID string)
-	GetAccount(accountID string)
-	ListAccounts()
+	AddAccount(ctx context.Context, accountID, adminRoleARN string) error
+	RemoveAccount(ctx context.Context, accountID string) error
+	GetAccount(ctx context.Context, accountID string) error
+	ListAccounts(ctx context.Context, opts *AccountListOptions) error
 }
 
 type LeaseLoginOptions struct {
@@ -80,19 +140,71 @@ type LeaseLoginOptions struct {
 	PrintCreds  bool
 }
 
+type LeaseCreateOptions struct {
+	// Wait polls the lease until it is active, and its account is leased
+	Wait bool
+	// WaitTimeout is how long to wait. Defaults to DefaultLeaseWaitTimeout.
+	WaitTimeout time.Duration
+	// Login logs in to the leased account once the lease is active, if set.
+	// Implies Wait.
+	Login *LeaseLoginOptions
+}
+
+type LeaseListOptions struct {
+	AccountID       string
+	PrincipalID     string
+	NextAccountID   string
+	NextPrincipalID string
+	Status          string
+	// Limit is the number of leases to request per page
+	Limit int64
+	// All follows pagination links, until all leases have been fetched
+	All bool
+	// MaxItems caps the number of leases returned. Follows pagination links until reached.
+	MaxItems int64
+}
+
+type LeaseWatchOptions struct {
+	// Interval is how often to poll the lease. Defaults to DefaultWatchInterval.
+	Interval time.Duration
+}
+
 type Leaser interface {
-	CreateLease(principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string)
-	EndLease(leaseID, accountID, principalID string)
-	LoginByID(leaseID string, opts *LeaseLoginOptions)
-	Login(opts *LeaseLoginOptions)
-	ListLeases(acctID, principalID, nextAcctID, nextPrincipalID, leaseStatus string, pagLimit int64)
-	GetLease(leaseID string)
+	CreateLease(ctx context.Context, principalID string, budgetAmount float64, budgetCurrency string, email []string, expiresOn string, opts *LeaseCreateOptions) error
+	CreateLeasesFromFile(ctx context.Context, file string, overrides *LeaseSpec, opts *LeaseCreateOptions) error
+	EndLease(ctx context.Context, leaseID, accountID, principalID string) error
+	LoginByID(ctx context.Context, leaseID string, opts *LeaseLoginOptions) error
+	Login(ctx context.Context, opts *LeaseLoginOptions) error
+	ListLeases(ctx context.Context, opts *LeaseListOptions) error
+	GetLease(ctx context.Context, leaseID string) error
+	WatchLease(ctx context.Context, leaseID string, opts *LeaseWatchOptions) error
+	Exec(ctx context.Context, leaseID string, command []string) error
+	Shell(ctx context.Context, leaseID string) error
+	CredentialProcess(ctx context.Context, leaseID string) error
+	ConfigureCredentialProcess(leaseID string, profile string) error
+}
+
+type Contexter interface {
+	ListContexts() error
+	UseContext(name string) error
+	AddContext(name string, settings *configs.Context) error
+	RemoveContext(name string) error
+}
+
+type Configer interface {
+	ViewConfig() error
+	GetConfig(key string) error
+	SetConfig(key string, value string) error
+	UnsetConfig(key string) error
+	ValidateConfig() error
 }
 
 type Initer interface {
-	InitializeDCE()
+	InitializeDCE() error
 }
 
 type Authenticater interface {
-	Authenticate() error
+	Authenticate(ctx context.Context, opts *AuthOptions) error
+	AuthStatus(ctx context.Context) error
+	Logout() error
 }
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	utl "github.com/Optum/dce-cli/internal/util"
+)
+
+// LeaseShellEnv is set in `dce leases shell` to the lease ID,
+// or "active" for the active lease of the requesting user
+const LeaseShellEnv = "DCE_LEASE_SHELL"
+
+// LeaseExpiresOnEnv is set in `dce leases shell` to the
+// expiry of the lease, as a UNIX epoch time, if known
+const LeaseExpiresOnEnv = "DCE_LEASE_EXPIRES_ON"
+
+// Credentials served to `dce leases shell` are refreshed this long before
+// they expire. The AWS CLI refreshes credentials 15 minutes before they expire,
+// and refuses credentials which expire within 10 minutes.
+const shellCredentialRefreshWindow = 15 * time.Minute
+
+// Shell starts the user's shell, configured to use lease credentials.
+// If no lease ID is provided, uses the active lease for the requesting user.
+// Credentials are served to AWS tools by a localhost credentials endpoint,
+// which refreshes them before they expire. The lease is shown in the prompt of bash and zsh.
+func (s *LeasesService) Shell(ctx context.Context, leaseID string) error {
+	if name, ok := os.LookupEnv(LeaseShellEnv); ok {
+		return NewValidationError("already in a shell for lease %s. Run `exit` to leave it first", name)
+	}
+
+	creds, err := s.getLeaseCreds(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	name := "active"
+	var expiresOn time.Time
+	if leaseID != "" {
+		name = leaseID
+		params := &operations.GetLeasesIDParams{
+			ID: leaseID,
+		}
+		reqCtx, cancel := requestContext(ctx)
+		defer cancel()
+		params.SetContext(reqCtx)
+		res, err := ApiClient.GetLeasesID(params, nil)
+		if err != nil {
+			return apiError("get lease", err)
+		}
+		if res.GetPayload().ExpiresOn > 0 {
+			expiresOn = time.Unix(int64(res.GetPayload().ExpiresOn), 0)
+		}
+	}
+
+	// Credentials are refreshed in the background, while the shell is reading
+	// from the terminal, so dce must not prompt the user to log in again
+	refreshCtx := utl.WithoutReauthentication(ctx)
+	warnedExpired := false
+	server, err := utl.StartCredentialServer(containerCredentials(creds), shellCredentialRefreshWindow,
+		func() (*utl.ContainerCredentials, error) {
+			log.Debugln("Refreshing leased account credentials")
+			creds, err := s.getLeaseCreds(refreshCtx, leaseID)
+			if err != nil {
+				if KindOf(err) != UnauthorizedError {
+					log.Warnf("Failed to refresh leased account credentials: %s", err)
+				} else if !warnedExpired {
+					log.Warnln("Unable to refresh leased account credentials, as your DCE credentials have expired. " +
+						"Run `dce auth` in another terminal to log in again, then start a new `dce leases shell`.")
+					warnedExpired = true
+				}
+				return nil, err
+			}
+			return containerCredentials(creds), nil
+		})
+	if err != nil {
+		return newError("start credentials server", err)
+	}
+	defer server.Close()
+
+	shell := userShell()
+	args, promptVars, cleanup, err := shellPrompt(shell, name)
+	if err != nil {
+		return newError("configure shell prompt", err)
+	}
+	defer cleanup()
+
+	// Lease credentials are not set in the environment, as AWS SDKs
+	// would use them in preference to the credentials endpoint,
+	// and fail when they expire
+	vars := []string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI=" + server.URL,
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN=" + server.AuthToken,
+		LeaseShellEnv + "=" + name,
+	}
+	if !expiresOn.IsZero() {
+		vars = append(vars, LeaseExpiresOnEnv+"="+strconv.FormatInt(expiresOn.Unix(), 10))
+	}
+
+	child := exec.Command(shell, args...)
+	child.Env = s.leaseEnv(os.Environ(), append(vars, promptVars...)...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	log.Infof("Starting %s with credentials for the %s lease. Run `exit` to leave.", filepath.Base(shell), name)
+	return runForwardingSignals(child)
+}
+
+// containerCredentials converts lease credentials
+// to the format served by the credentials endpoint
+func containerCredentials(creds *leaseCreds) *utl.ContainerCredentials {
+	expiration := time.Now().Add(defaultLeaseCredsDuration)
+	if creds.ExpiresOn > 0 {
+		expiration = time.Unix(int64(creds.ExpiresOn), 0)
+	}
+	return &utl.ContainerCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		Token:           creds.SessionToken,
+		Expiration:      expiration.UTC(),
+	}
+}
+
+// userShell returns the path of the user's shell
+func userShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		if shell := os.Getenv("COMSPEC"); shell != "" {
+			return shell
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+// shellPromptFunc prints the lease name and the time left
+// until the lease expires, for bash and zsh prompts
+const shellPromptFunc = `__dce_prompt() {
+  if [ -n "$DCE_LEASE_EXPIRES_ON" ]; then
+    __dce_left=$(( (DCE_LEASE_EXPIRES_ON - $(date +%s)) / 60 ))
+    [ "$__dce_left" -lt 0 ] && __dce_left=0
+    if [ "$__dce_left" -ge 1440 ]; then
+      printf '(dce:%s %dd %dh) ' "$DCE_LEASE_SHELL" $((__dce_left / 1440)) $((__dce_left % 1440 / 60))
+    else
+      printf '(dce:%s %dh %dm) ' "$DCE_LEASE_SHELL" $((__dce_left / 60)) $((__dce_left % 60))
+    fi
+  else
+    printf '(dce:%s) ' "$DCE_LEASE_SHELL"
+  fi
+}
+`
+
+// bashrc is run in place of ~/.bashrc, with --rcfile
+const bashrc = `[ -f ~/.bashrc ] && . ~/.bashrc
+` + shellPromptFunc + `PS1='$(__dce_prompt)'"$PS1"
+`
+
+// zsh reads its startup files from $ZDOTDIR, which is set to a
+// temporary directory, so these files run the user's own startup files,
+// then restore $ZDOTDIR.
+const zshenv = `__dce_zdotdir="$ZDOTDIR"
+ZDOTDIR="${DCE_ORIG_ZDOTDIR:-$HOME}"
+[ -f "$ZDOTDIR/.zshenv" ] && . "$ZDOTDIR/.zshenv"
+DCE_ORIG_ZDOTDIR="$ZDOTDIR"
+ZDOTDIR="$__dce_zdotdir"
+unset __dce_zdotdir
+`
+
+const zshrc = `ZDOTDIR="$DCE_ORIG_ZDOTDIR"
+unset DCE_ORIG_ZDOTDIR
+[ -f "$ZDOTDIR/.zshrc" ] && . "$ZDOTDIR/.zshrc"
+` + shellPromptFunc + `setopt PROMPT_SUBST
+PROMPT='$(__dce_prompt)'"$PROMPT"
+`
+
+// shellPrompt returns the args and env vars which add the lease to the
+// prompt of a shell, and a func to remove any temporary startup files.
+// Other shells are given a fixed PS1 prompt.
+func shellPrompt(shell string, name string) ([]string, []string, func(), error) {
+	noCleanup := func() {}
+	writeStartupFiles := func(files map[string]string) (string, func(), error) {
+		dir, err := ioutil.TempDir("", "dce-shell")
+		if err != nil {
+			return "", noCleanup, err
+		}
+		cleanup := func() { _ = os.RemoveAll(dir) }
+		for file, content := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0600); err != nil {
+				cleanup()
+				return "", noCleanup, err
+			}
+		}
+		return dir, cleanup, nil
+	}
+
+	marker := "(dce:" + name + ") "
+	switch strings.TrimSuffix(filepath.Base(shell), ".exe") {
+	case "bash":
+		dir, cleanup, err := writeStartupFiles(map[string]string{"bashrc": bashrc})
+		if err != nil {
+			return nil, nil, noCleanup, err
+		}
+		return []string{"--rcfile", filepath.Join(dir, "bashrc")}, nil, cleanup, nil
+	case "zsh":
+		dir, cleanup, err := writeStartupFiles(map[string]string{".zshenv": zshenv, ".zshrc": zshrc})
+		if err != nil {
+			return nil, nil, noCleanup, err
+		}
+		return nil, []string{"ZDOTDIR=" + dir, "DCE_ORIG_ZDOTDIR=" + os.Getenv("ZDOTDIR")}, cleanup, nil
+	case "cmd":
+		prompt := os.Getenv("PROMPT")
+		if prompt == "" {
+			prompt = "$P$G"
+		}
+		return nil, []string{"PROMPT=" + marker + prompt}, noCleanup, nil
+	default:
+		prompt := os.Getenv("PS1")
+		if prompt == "" {
+			prompt = "$ "
+		}
+		return nil, []string{"PS1=" + marker + prompt}, noCleanup, nil
+	}
+}
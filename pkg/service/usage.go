@@ -1,13 +1,13 @@
 package service
 
 import (
-	"encoding/json"
-	"time"
+	"context"
 
 	"github.com/Optum/dce-cli/client/operations"
 	"github.com/Optum/dce-cli/configs"
 	observ "github.com/Optum/dce-cli/internal/observation"
 	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/Optum/dce-cli/models"
 )
 
 type UsageService struct {
@@ -16,22 +16,18 @@ type UsageService struct {
 	Util        *utl.UtilContainer
 }
 
-func (s *UsageService) GetUsage(startDate, endDate float64) {
+func (s *UsageService) GetUsage(ctx context.Context, startDate, endDate float64) error {
 	params := &operations.GetUsageParams{
 		StartDate: startDate,
 		EndDate:   endDate,
 	}
-	params.SetTimeout(5 * time.Second)
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	params.SetContext(reqCtx)
 	res, err := ApiClient.GetUsage(params, nil)
 	if err != nil {
-		log.Fatalln("err: ", err)
-	} else {
-		jsonPayload, err := json.MarshalIndent(res.GetPayload(), "", "\t")
-		if err != nil {
-			log.Fatalln("err: ", err)
-		}
-		if _, err := Out.Write(jsonPayload); err != nil {
-			log.Fatalln("err: ", err)
-		}
+		return apiError("get usage", err)
 	}
+	usage := models.Usage(*res.GetPayload())
+	return writeOutput(&usage)
 }
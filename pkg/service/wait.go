@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/models"
+)
+
+// DefaultLeaseWaitTimeout is how long `dce leases create --wait`
+// waits for a lease to become active
+const DefaultLeaseWaitTimeout = 10 * time.Minute
+
+// LeaseWaitInterval is how often `dce leases create --wait` checks the lease
+var LeaseWaitInterval = 5 * time.Second
+
+// Progress is logged when the lease or account status changes, or after this long
+const leaseWaitProgressInterval = 30 * time.Second
+
+// waitForLease polls a new lease until it is active, and its account is leased.
+// Principals may not have permission to view accounts, in which case
+// only the lease status is checked.
+func (s *LeasesService) waitForLease(ctx context.Context, lease *models.Lease, timeout time.Duration) (*models.Lease, error) {
+	if timeout == 0 {
+		timeout = DefaultLeaseWaitTimeout
+	}
+	if timeout < 0 {
+		return nil, NewValidationError("invalid wait timeout \"%s\": must be positive", timeout)
+	}
+	if lease.ID == "" {
+		return nil, newError("wait for lease", errors.New("the DCE API did not return a lease ID"))
+	}
+
+	start := time.Now()
+	checkAccount := lease.AccountID != ""
+	progress := ""
+	var progressLogged time.Time
+	log.Infof("Waiting up to %s for lease %s to become active...", timeout, lease.ID)
+	for {
+		current, accountStatus, err := s.pollNewLease(ctx, lease, &checkAccount)
+		switch KindOf(err) {
+		case NetworkError, TimeoutError, ServerError:
+			// Keep waiting through transient failures
+			svcErr := err.(*Error)
+			log.Warnf("Failed to %s: %s. Retrying in %s", svcErr.Op, svcErr.detail(), LeaseWaitInterval)
+		default:
+			if err != nil {
+				return nil, err
+			}
+			status := "lease " + current.LeaseStatus
+			if checkAccount {
+				status += ", account " + accountStatus
+			}
+			elapsed := time.Since(start).Round(time.Second)
+			if status != progress || time.Since(progressLogged) >= leaseWaitProgressInterval {
+				log.Infof("Lease %s: %s (%s elapsed)", lease.ID, status, elapsed)
+				progress, progressLogged = status, time.Now()
+			}
+
+			if current.LeaseStatus == string(models.LeaseStatusInactive) {
+				return nil, &Error{Kind: LeaseEndedError, Op: "wait for lease",
+					Err: fmt.Errorf("lease %s ended before it became active (%s)", lease.ID, current.LeaseStatusReason)}
+			}
+			if current.LeaseStatus == string(models.LeaseStatusActive) &&
+				(!checkAccount || accountStatus == string(models.AccountStatusLeased)) {
+				log.Infof("Lease %s is active", lease.ID)
+				return current, nil
+			}
+		}
+
+		if time.Since(start)+LeaseWaitInterval > timeout {
+			return nil, &Error{Kind: TimeoutError, Op: "wait for lease",
+				Err:  fmt.Errorf("lease %s did not become active within %s", lease.ID, timeout),
+				Hint: fmt.Sprintf("Increase the timeout with --wait-timeout, or check the lease with `dce leases describe %s`.", lease.ID)}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &Error{Kind: CanceledError, Op: "wait for lease", Err: ctx.Err()}
+		case <-time.After(LeaseWaitInterval):
+		}
+	}
+}
+
+// pollNewLease fetches a lease, and the status of its account if checkAccount is set.
+// checkAccount is cleared if the user does not have permission to view the account.
+func (s *LeasesService) pollNewLease(ctx context.Context, lease *models.Lease, checkAccount *bool) (*models.Lease, string, error) {
+	leaseParams := &operations.GetLeasesIDParams{
+		ID: lease.ID,
+	}
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	leaseParams.SetContext(reqCtx)
+	leaseRes, err := ApiClient.GetLeasesID(leaseParams, nil)
+	if err != nil {
+		return nil, "", apiError("get lease", err)
+	}
+	current := models.Lease(*leaseRes.GetPayload())
+	if !*checkAccount {
+		return &current, "", nil
+	}
+
+	accountParams := &operations.GetAccountsIDParams{
+		ID: lease.AccountID,
+	}
+	accountCtx, cancelAccount := requestContext(ctx)
+	defer cancelAccount()
+	accountParams.SetContext(accountCtx)
+	accountRes, err := ApiClient.GetAccountsID(accountParams, nil)
+	if err != nil {
+		err = apiError("get account", err)
+		if KindOf(err) == UnauthorizedError {
+			log.Debugf("Not checking the status of account %s: %s", lease.AccountID, err)
+			*checkAccount = false
+			return &current, "", nil
+		}
+		return nil, "", err
+	}
+	return &current, accountRes.GetPayload().AccountStatus, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/models"
+)
+
+// DefaultWatchInterval is how often `dce leases watch` polls the DCE API
+const DefaultWatchInterval = 30 * time.Second
+
+// leaseState is the state of a lease, as seen by one poll of `dce leases watch`
+type leaseState struct {
+	Status       string
+	StatusReason string
+	Budget       float64
+	Currency     string
+	Cost         float64
+	ExpiresOn    time.Time
+}
+
+// Remaining is the budget which has not been spent
+func (s *leaseState) Remaining() float64 {
+	return s.Budget - s.Cost
+}
+
+// WatchLease polls a lease until it ends, logging the remaining budget,
+// the time until the lease expires, and changes to the lease status.
+// Returns a LeaseOverBudgetError if the lease is over budget, and a
+// LeaseEndedError if the lease expired or was ended for another reason.
+func (s *LeasesService) WatchLease(ctx context.Context, leaseID string, opts *LeaseWatchOptions) error {
+	interval := opts.Interval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+	if interval < 0 {
+		return NewValidationError("invalid interval \"%s\": must be positive", interval)
+	}
+
+	log.Infof("Watching lease %s every %s. Press Ctrl-C to stop.", leaseID, interval)
+	var prev *leaseState
+	for {
+		state, err := s.pollLease(ctx, leaseID)
+		switch KindOf(err) {
+		case NetworkError, TimeoutError, ServerError:
+			// Keep watching through transient failures
+			svcErr := err.(*Error)
+			log.Warnf("Failed to %s: %s. Retrying in %s", svcErr.Op, svcErr.detail(), interval)
+		default:
+			if err != nil {
+				return err
+			}
+			logLeaseState(leaseID, prev, state)
+			if err := leaseEndedError(leaseID, state, time.Now()); err != nil {
+				return err
+			}
+			prev = state
+		}
+
+		select {
+		case <-ctx.Done():
+			return &Error{Kind: CanceledError, Op: "watch lease", Err: ctx.Err()}
+		case <-time.After(interval):
+		}
+	}
+}
+
+// pollLease fetches a lease, and the cost of its account since the lease was created
+func (s *LeasesService) pollLease(ctx context.Context, leaseID string) (*leaseState, error) {
+	leaseParams := &operations.GetLeasesIDParams{
+		ID: leaseID,
+	}
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	leaseParams.SetContext(reqCtx)
+	leaseRes, err := ApiClient.GetLeasesID(leaseParams, nil)
+	if err != nil {
+		return nil, apiError("get lease", err)
+	}
+	lease := leaseRes.GetPayload()
+
+	usageParams := &operations.GetUsageParams{
+		StartDate: lease.CreatedOn,
+		EndDate:   float64(time.Now().Unix()),
+	}
+	usageCtx, cancelUsage := requestContext(ctx)
+	defer cancelUsage()
+	usageParams.SetContext(usageCtx)
+	usageRes, err := ApiClient.GetUsage(usageParams, nil)
+	if err != nil {
+		return nil, apiError("get usage", err)
+	}
+
+	state := &leaseState{
+		Status:       lease.LeaseStatus,
+		StatusReason: lease.LeaseStatusReason,
+		Budget:       lease.BudgetAmount,
+		Currency:     lease.BudgetCurrency,
+	}
+	if lease.ExpiresOn > 0 {
+		state.ExpiresOn = time.Unix(int64(lease.ExpiresOn), 0)
+	}
+	state.Cost = leaseCost(lease, usageRes.GetPayload())
+	return state, nil
+}
+
+// leaseCost sums the cost of usage records for the leased account.
+// GetUsage isn't filtered by account, so records for other accounts,
+// or without an account, are not counted.
+func leaseCost(lease *operations.GetLeasesIDOKBody, records ...*operations.GetUsageOKBody) float64 {
+	cost := 0.0
+	for _, usage := range records {
+		if usage == nil || lease.AccountID == "" || usage.AccountID != lease.AccountID {
+			continue
+		}
+		if usage.PrincipalID != "" && usage.PrincipalID != lease.PrincipalID {
+			continue
+		}
+		cost += usage.CostAmount
+	}
+	return cost
+}
+
+// logLeaseState logs the remaining budget and time of a lease,
+// and any change in its status since the previous poll
+func logLeaseState(leaseID string, prev *leaseState, state *leaseState) {
+	if prev != nil && (prev.Status != state.Status || prev.StatusReason != state.StatusReason) {
+		log.Warnf("Lease %s status changed from %s to %s (%s)",
+			leaseID, prev.Status, state.Status, state.StatusReason)
+	}
+
+	expires := "no expiry"
+	if !state.ExpiresOn.IsZero() {
+		expires = "expires in " + formatTimeLeft(time.Until(state.ExpiresOn))
+	}
+	log.Infof("Lease %s is %s: %.2f of %.2f %s budget remaining, %s",
+		leaseID, state.Status, state.Remaining(), state.Budget, state.Currency, expires)
+}
+
+// leaseEndedError returns an error if the lease is over budget,
+// expired, or otherwise no longer active
+func leaseEndedError(leaseID string, state *leaseState, now time.Time) error {
+	overBudget := state.StatusReason == string(models.LeaseStatusReasonLeaseOverBudget) ||
+		(state.Budget > 0 && state.Remaining() <= 0)
+	if overBudget {
+		return &Error{Kind: LeaseOverBudgetError, Op: "watch lease",
+			Err: fmt.Errorf("lease %s is over budget: spent %.2f of %.2f %s",
+				leaseID, state.Cost, state.Budget, state.Currency)}
+	}
+
+	if !state.ExpiresOn.IsZero() && !now.Before(state.ExpiresOn) {
+		return &Error{Kind: LeaseEndedError, Op: "watch lease",
+			Err: fmt.Errorf("lease %s expired at %s", leaseID, state.ExpiresOn.Format(time.RFC1123))}
+	}
+	if state.StatusReason == string(models.LeaseStatusReasonLeaseExpired) {
+		return &Error{Kind: LeaseEndedError, Op: "watch lease",
+			Err: fmt.Errorf("lease %s expired", leaseID)}
+	}
+
+	if state.Status == string(models.LeaseStatusInactive) {
+		return &Error{Kind: LeaseEndedError, Op: "watch lease",
+			Err: fmt.Errorf("lease %s is no longer active (%s)", leaseID, state.StatusReason)}
+	}
+	return nil
+}
+
+// formatTimeLeft formats a duration to the minute, eg. "6d 23h 5m"
+func formatTimeLeft(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	d = d.Truncate(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	parts := []string{}
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if days > 0 || hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	parts = append(parts, fmt.Sprintf("%dm", minutes))
+	return strings.Join(parts, " ")
+}
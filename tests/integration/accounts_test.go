@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsList(t *testing.T) {
+
+	t.Run("GIVEN filter flags and --all", func(t *testing.T) {
+
+		t.Run("THEN filters should be sent to the API, and all pages returned", func(t *testing.T) {
+			cli := NewCLITest(t)
+
+			isFiltered := func(params *operations.GetAccountsParams) bool {
+				return *params.Status == "Orphaned" &&
+					*params.PrincipalPolicyHash == "old-hash"
+			}
+
+			api := &mocks.APIer{}
+			api.On("GetAccounts", mock.MatchedBy(func(params *operations.GetAccountsParams) bool {
+				return isFiltered(params) && (params.NextID == nil || *params.NextID == "")
+			}), nil).Return(&operations.GetAccountsOK{
+				Link: `<https://dce.example.com/api/accounts?status=Orphaned&nextId=111>; rel="next"`,
+				Payload: []*operations.GetAccountsOKBodyItems0{
+					{ID: "111", AccountStatus: "Orphaned"},
+				},
+			}, nil).Once()
+			api.On("GetAccounts", mock.MatchedBy(func(params *operations.GetAccountsParams) bool {
+				return isFiltered(params) && params.NextID != nil && *params.NextID == "111"
+			}), nil).Return(&operations.GetAccountsOK{
+				Payload: []*operations.GetAccountsOKBodyItems0{
+					{ID: "222", AccountStatus: "Orphaned"},
+				},
+			}, nil).Once()
+
+			var actualIDs []string
+			out := &mocks.OutputWriter{}
+			out.On("Write", mock.MatchedBy(func(out []byte) bool {
+				var accounts []map[string]interface{}
+				require.Nil(t, json.Unmarshal(out, &accounts))
+				for _, account := range accounts {
+					actualIDs = append(actualIDs, account["id"].(string))
+				}
+				return true
+			})).Return(0, nil)
+
+			authSvc := &mocks.Authenticater{}
+			authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+			cli.Inject(func(input *injectorInput) {
+				service.ApiClient = api
+				service.Out = out
+				input.service.Authenticater = authSvc
+			})
+
+			err := cli.Execute([]string{
+				"accounts", "list",
+				"--status", "Orphaned",
+				"--principal-policy-hash", "old-hash",
+				"--all",
+			})
+			require.Nil(t, err)
+
+			api.AssertExpectations(t)
+			require.Equal(t, []string{"111", "222"}, actualIDs)
+		})
+	})
+}
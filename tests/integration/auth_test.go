@@ -1,12 +1,28 @@
 package integration
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
 	"github.com/Optum/dce-cli/configs"
+	utl "github.com/Optum/dce-cli/internal/util"
 	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/thriftrw/ptr"
-	"testing"
+	"gopkg.in/yaml.v2"
 )
 
 func TestAuthCommand(t *testing.T) {
@@ -64,8 +80,344 @@ func TestAuthCommand(t *testing.T) {
 					"Unable to authenticate against DCE API: missing API configuration",
 					err.Error(),
 				)
+				require.Equal(t, service.ValidationError, service.KindOf(err))
+			})
+
+		})
+
+		t.Run("AND --mode loopback is set", func(t *testing.T) {
+
+			t.Run("THEN the API token should be received from the auth page redirect", func(t *testing.T) {
+				cli := NewCLITest(t)
+				defer resetFlag(t, []string{"auth"}, "mode", "")
+
+				confFile := writeTempConfig(t, &configs.Root{
+					API: configs.API{
+						Host:     ptr.String("dce.example.com"),
+						BasePath: ptr.String("/api"),
+					},
+				})
+
+				// Mock the auth page, by redirecting to the localhost server
+				// as soon as the browser is opened
+				token := newAPIToken(t, time.Now().Add(time.Hour))
+				mockWeber := &mocks.Weber{}
+				mockWeber.On("OpenURL", mock.MatchedBy(func(authURL string) bool {
+					return strings.HasPrefix(authURL, "https://dce.example.com/api/auth?redirect_uri=")
+				})).Run(func(args mock.Arguments) {
+					authURL, err := url.Parse(args.String(0))
+					require.Nil(t, err)
+					redirectURL := authURL.Query().Get("redirect_uri") + "&token=" + url.QueryEscape(token)
+
+					res, err := http.Get(redirectURL)
+					require.Nil(t, err)
+					require.Equal(t, http.StatusOK, res.StatusCode)
+				})
+				cli.Inject(func(input *injectorInput) {
+					input.service.Util.Weber = mockWeber
+				})
+
+				err := cli.Execute([]string{"auth", "--mode", "loopback", "--config", confFile})
+				require.Nil(t, err)
+				mockWeber.AssertExpectations(t)
+
+				var savedConfig configs.Root
+				confYaml, err := ioutil.ReadFile(confFile)
+				require.Nil(t, err)
+				require.Nil(t, yaml.Unmarshal(confYaml, &savedConfig))
+				require.Equal(t, token, *savedConfig.API.Token)
+			})
+
+			t.Run("THEN an expired API token should be rejected", func(t *testing.T) {
+				cli := NewCLITest(t)
+				defer resetFlag(t, []string{"auth"}, "mode", "")
+
+				confFile := writeTempConfig(t, &configs.Root{
+					API: configs.API{
+						Host:     ptr.String("dce.example.com"),
+						BasePath: ptr.String("/api"),
+					},
+				})
+
+				token := newAPIToken(t, time.Now().Add(-time.Hour))
+				mockWeber := &mocks.Weber{}
+				mockWeber.On("OpenURL", mock.Anything).Run(func(args mock.Arguments) {
+					authURL, err := url.Parse(args.String(0))
+					require.Nil(t, err)
+					redirectURL := authURL.Query().Get("redirect_uri") + "&token=" + url.QueryEscape(token)
+
+					res, err := http.Get(redirectURL)
+					require.Nil(t, err)
+					require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				})
+				cli.Inject(func(input *injectorInput) {
+					input.service.Util.Weber = mockWeber
+				})
+
+				err := cli.Execute([]string{"auth", "--mode", "loopback", "--config", confFile})
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), "token is expired")
+			})
+		})
+	})
+}
+
+// newAPIToken returns a base64 encoded API token,
+// as would be provided by the DCE auth page
+func newAPIToken(t *testing.T, expiration time.Time) string {
+	tokenJSON, err := json.Marshal(utl.APITokenValue{
+		AccessKeyID:     "access-key-id",
+		SecretAccessKey: "secret-access-key",
+		SessionToken:    "session-token",
+		Expiration:      expiration.Unix(),
+	})
+	require.Nil(t, err)
+	return base64.StdEncoding.EncodeToString(tokenJSON)
+}
+
+func TestAuthStatusCommand(t *testing.T) {
+
+	t.Run("GIVEN a valid API token", func(t *testing.T) {
+
+		t.Run("THEN auth status should show the token expiry and caller identity", func(t *testing.T) {
+			cli := NewCLITest(t)
+
+			expiration := time.Now().Add(2 * time.Hour)
+			cli.WriteConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("dce.example.com"),
+					BasePath: ptr.String("/api"),
+					Token:    ptr.String(newAPIToken(t, expiration)),
+				},
+			})
+
+			awser := &mocks.AWSer{}
+			awser.On("GetCallerIdentity", mock.Anything).Return(&sts.GetCallerIdentityOutput{
+				Account: aws.String("123456789012"),
+				Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/DCEPrincipal/jdoe"),
+				UserId:  aws.String("AROAEXAMPLE:jdoe"),
+			}, nil)
+
+			var status map[string]interface{}
+			out := &mocks.OutputWriter{}
+			out.On("Write", mock.Anything).Run(func(args mock.Arguments) {
+				require.Nil(t, json.Unmarshal(args.Get(0).([]byte), &status))
+			}).Return(0, nil)
+
+			cli.Inject(func(input *injectorInput) {
+				input.service.Util.AWSer = awser
+				service.Out = out
+			})
+
+			err := cli.Execute([]string{"auth", "status"})
+			require.Nil(t, err)
+
+			require.Equal(t, "https://dce.example.com/api", status["apiEndpoint"])
+			require.Equal(t, utl.APITokenProviderName, status["credentialProvider"])
+			require.Equal(t, float64(expiration.Unix()), status["tokenExpiresOn"])
+			require.Equal(t, "123456789012", status["account"])
+			require.Equal(t, "arn:aws:sts::123456789012:assumed-role/DCEPrincipal/jdoe", status["arn"])
+		})
+	})
+
+	t.Run("GIVEN no credentials", func(t *testing.T) {
+
+		t.Run("THEN auth status should fail as unauthorized", func(t *testing.T) {
+			cli := NewCLITest(t)
+			cli.WriteConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("dce.example.com"),
+					BasePath: ptr.String("/api"),
+					Token:    ptr.String(newAPIToken(t, time.Now().Add(-time.Hour))),
+				},
+			})
+			// Make sure credentials aren't found in the environment
+			os.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(os.TempDir(), "dce-missing-credentials"))
+			defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+			os.Unsetenv("AWS_ACCESS_KEY_ID")
+			os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+			out := &mocks.OutputWriter{}
+			out.On("Write", mock.Anything).Return(0, nil)
+			cli.Inject(func(input *injectorInput) {
+				service.Out = out
+			})
+
+			err := cli.Execute([]string{"auth", "status"})
+			require.NotNil(t, err)
+			require.Equal(t, service.UnauthorizedError, service.KindOf(err))
+		})
+	})
+}
+
+func TestAuthLogoutCommand(t *testing.T) {
+
+	t.Run("GIVEN lease credentials were written to AWS CLI profiles", func(t *testing.T) {
+
+		t.Run("THEN auth logout should remove the profiles, and the API token", func(t *testing.T) {
+			cli := NewCLITest(t)
+
+			confFile := writeTempConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("dce.example.com"),
+					BasePath: ptr.String("/api"),
+					Token:    ptr.String(newAPIToken(t, time.Now().Add(time.Hour))),
+				},
+				AWSProfiles: []string{"dce-lease", "dce"},
+			})
+
+			credsFile, err := ioutil.TempFile("", "credentials")
+			require.Nil(t, err)
+			defer os.Remove(credsFile.Name())
+			_, err = credsFile.WriteString(strings.Join([]string{
+				"# My personal credentials",
+				"[personal]",
+				"aws_access_key_id = personal-key",
+				"",
+				"[dce-lease]",
+				"aws_access_key_id = lease-key",
+				"aws_secret_access_key = lease-secret",
+				"",
+				"[work]",
+				"aws_access_key_id = work-key",
+				"",
+			}, "\n"))
+			require.Nil(t, err)
+			require.Nil(t, credsFile.Close())
+			os.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile.Name())
+			defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+
+			// `dce leases login` sets the region of its profiles, and
+			// `dce leases configure-profile` adds credential_process profiles
+			awsConfig, err := ioutil.TempFile("", "aws-config")
+			require.Nil(t, err)
+			defer os.Remove(awsConfig.Name())
+			_, err = awsConfig.WriteString(strings.Join([]string{
+				"[profile dce-lease]",
+				"region = us-east-1",
+				"",
+				"[profile dce]",
+				"credential_process = dce leases credential-process",
+				"",
+				"[profile work]",
+				"region = us-west-2",
+				"",
+			}, "\n"))
+			require.Nil(t, err)
+			require.Nil(t, awsConfig.Close())
+			os.Setenv("AWS_CONFIG_FILE", awsConfig.Name())
+			defer os.Unsetenv("AWS_CONFIG_FILE")
+
+			configDir, err := ioutil.TempDir("", "dce-config")
+			require.Nil(t, err)
+			defer os.RemoveAll(configDir)
+			cli.Inject(func(input *injectorInput) {
+				input.service.Util.FileSystemer.(*utl.FileSystemUtil).ConfigDir = configDir
 			})
 
+			err = cli.Execute([]string{"auth", "logout", "--config", confFile})
+			require.Nil(t, err)
+
+			credsAfter, err := ioutil.ReadFile(credsFile.Name())
+			require.Nil(t, err)
+			require.Equal(t, strings.Join([]string{
+				"# My personal credentials",
+				"[personal]",
+				"aws_access_key_id = personal-key",
+				"",
+				"[work]",
+				"aws_access_key_id = work-key",
+				"",
+			}, "\n"), string(credsAfter))
+
+			awsConfigAfter, err := ioutil.ReadFile(awsConfig.Name())
+			require.Nil(t, err)
+			require.Equal(t, strings.Join([]string{
+				"[profile work]",
+				"region = us-west-2",
+				"",
+			}, "\n"), string(awsConfigAfter))
+
+			var savedConfig configs.Root
+			confYaml, err := ioutil.ReadFile(confFile)
+			require.Nil(t, err)
+			require.Nil(t, yaml.Unmarshal(confYaml, &savedConfig))
+			require.Nil(t, savedConfig.API.Token)
+			require.Empty(t, savedConfig.AWSProfiles)
+		})
+	})
+
+	t.Run("GIVEN lease credentials were written to the default profile, with the user's own settings", func(t *testing.T) {
+
+		t.Run("THEN auth logout should only remove the settings written by dce", func(t *testing.T) {
+			cli := NewCLITest(t)
+
+			confFile := writeTempConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("dce.example.com"),
+					BasePath: ptr.String("/api"),
+				},
+				AWSProfiles: []string{"default"},
+			})
+
+			// writeAWSFile writes an AWS CLI file, and sets the env var for its location
+			writeAWSFile := func(envVar string, lines ...string) string {
+				file, err := ioutil.TempFile("", "aws")
+				require.Nil(t, err)
+				_, err = file.WriteString(strings.Join(lines, "\n"))
+				require.Nil(t, err)
+				require.Nil(t, file.Close())
+				os.Setenv(envVar, file.Name())
+				return file.Name()
+			}
+			credsFile := writeAWSFile("AWS_SHARED_CREDENTIALS_FILE",
+				"[default]",
+				"aws_access_key_id = lease-key",
+				"aws_secret_access_key = lease-secret",
+				"aws_session_token = lease-token",
+				"expiration = 2020-01-02T15:04:05Z",
+				"dce_lease_id = lease-1",
+				"mfa_serial = arn:aws:iam::123456789012:mfa/jdoe",
+				"",
+			)
+			defer os.Remove(credsFile)
+			defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+			awsConfig := writeAWSFile("AWS_CONFIG_FILE",
+				"[default]",
+				"region = us-east-1",
+				"output = json",
+				"sso_start_url = https://example.awsapps.com/start",
+				"",
+			)
+			defer os.Remove(awsConfig)
+			defer os.Unsetenv("AWS_CONFIG_FILE")
+
+			configDir, err := ioutil.TempDir("", "dce-config")
+			require.Nil(t, err)
+			defer os.RemoveAll(configDir)
+			cli.Inject(func(input *injectorInput) {
+				input.service.Util.FileSystemer.(*utl.FileSystemUtil).ConfigDir = configDir
+			})
+
+			err = cli.Execute([]string{"auth", "logout", "--config", confFile})
+			require.Nil(t, err)
+
+			credsAfter, err := ioutil.ReadFile(credsFile)
+			require.Nil(t, err)
+			require.Equal(t, strings.Join([]string{
+				"[default]",
+				"mfa_serial = arn:aws:iam::123456789012:mfa/jdoe",
+				"",
+			}, "\n"), string(credsAfter))
+
+			awsConfigAfter, err := ioutil.ReadFile(awsConfig)
+			require.Nil(t, err)
+			require.Equal(t, strings.Join([]string{
+				"[default]",
+				"output = json",
+				"sso_start_url = https://example.awsapps.com/start",
+				"",
+			}, "\n"), string(awsConfigAfter))
 		})
 	})
 }
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Optum/dce-cli/cmd"
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/ptr"
+)
+
+func TestAWSCredentialsOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aws")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	credsFile := filepath.Join(dir, "credentials")
+	require.Nil(t, ioutil.WriteFile(credsFile, []byte(strings.Join([]string{
+		"[default]",
+		"aws_access_key_id = default-key",
+		"aws_secret_access_key = default-secret",
+		"",
+		"[admin]",
+		"aws_access_key_id = admin-key",
+		"aws_secret_access_key = admin-secret",
+		"",
+	}, "\n")), 0600))
+	configFile := filepath.Join(dir, "config")
+	require.Nil(t, ioutil.WriteFile(configFile, []byte{}, 0600))
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+	defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+	os.Setenv("AWS_CONFIG_FILE", configFile)
+	defer os.Unsetenv("AWS_CONFIG_FILE")
+
+	t.Run("--aws-profile should set the credentials for AWS, the API and terraform", func(t *testing.T) {
+		cli := NewCLITest(t)
+		defer resetFlag(t, []string{}, "aws-profile", "")
+
+		err := cli.Execute([]string{"version", "--aws-profile", "admin"})
+		require.Nil(t, err)
+
+		creds, err := cmd.Util.AWSSession.Config.Credentials.Get()
+		require.Nil(t, err)
+		require.Equal(t, "admin-key", creds.AccessKeyID)
+
+		terraformCreds := cmd.Util.Terraformer.(*util.TerraformBinUtil).Credentials
+		require.Equal(t, cmd.Util.AWSSession.Config.Credentials, terraformCreds)
+	})
+
+	t.Run("aws.profile config should set the AWS credentials", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, &configs.Root{
+			AWS: configs.AWS{Profile: ptr.String("admin")},
+		})
+
+		err := cli.Execute([]string{"version"})
+		require.Nil(t, err)
+
+		creds, err := cmd.Util.AWSSession.Config.Credentials.Get()
+		require.Nil(t, err)
+		require.Equal(t, "admin-key", creds.AccessKeyID)
+	})
+
+	t.Run("terraform should find its own credentials by default", func(t *testing.T) {
+		cli := NewCLITest(t)
+
+		err := cli.Execute([]string{"version"})
+		require.Nil(t, err)
+
+		require.Nil(t, cmd.Util.Terraformer.(*util.TerraformBinUtil).Credentials)
+	})
+}
@@ -148,3 +148,11 @@ func writeTempConfig(t *testing.T, conf *configs.Root) string {
 
 	return tmpfile.Name()
 }
+
+// resetFlag resets a command flag after a test,
+// as cobra flag values persist between tests
+func resetFlag(t *testing.T, cmdPath []string, flag string, value string) {
+	c, _, err := cmd.RootCmd.Find(cmdPath)
+	require.Nil(t, err)
+	require.Nil(t, c.Flags().Set(flag, value))
+}
@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/ptr"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigCommands(t *testing.T) {
+
+	newConfig := func() *configs.Root {
+		return &configs.Root{
+			API: configs.API{
+				Host:     ptr.String("dce.example.com"),
+				BasePath: ptr.String("/api"),
+				Token:    ptr.String("my-api-token"),
+			},
+			Contexts: map[string]*configs.Context{
+				"prod": {
+					API: configs.API{
+						Host:  ptr.String("prod.example.com"),
+						Token: ptr.String("prod-api-token"),
+					},
+				},
+			},
+		}
+	}
+
+	// captureOutput records everything written to service.Out
+	captureOutput := func(cli *cliTest) *strings.Builder {
+		var output strings.Builder
+		out := &mocks.OutputWriter{}
+		out.On("Write", mock.Anything).Run(func(args mock.Arguments) {
+			output.Write(args.Get(0).([]byte))
+		}).Return(0, nil)
+		cli.Inject(func(input *injectorInput) {
+			service.Out = out
+		})
+		return &output
+	}
+
+	t.Run("config view should redact API tokens", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+		output := captureOutput(cli)
+
+		err := cli.Execute([]string{"config", "view"})
+		require.Nil(t, err)
+
+		var viewed configs.Root
+		require.Nil(t, yaml.Unmarshal([]byte(output.String()), &viewed))
+		require.Equal(t, "dce.example.com", *viewed.API.Host)
+		require.Equal(t, service.RedactedToken, *viewed.API.Token)
+		require.Equal(t, service.RedactedToken, *viewed.Contexts["prod"].API.Token)
+		require.NotContains(t, output.String(), "api-token")
+	})
+
+	t.Run("config get should redact API tokens in sections", func(t *testing.T) {
+		for _, key := range []string{"api", "contexts.prod", "contexts"} {
+			cli := NewCLITest(t)
+			cli.WriteConfig(t, newConfig())
+			output := captureOutput(cli)
+
+			err := cli.Execute([]string{"config", "get", key})
+			require.Nil(t, err)
+			require.Contains(t, output.String(), service.RedactedToken, key)
+			require.NotContains(t, output.String(), "api-token", key)
+		}
+	})
+
+	t.Run("config get should print a setting", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+		output := captureOutput(cli)
+
+		err := cli.Execute([]string{"config", "get", "contexts.prod.api.host"})
+		require.Nil(t, err)
+		require.Equal(t, "prod.example.com\n", output.String())
+
+		err = cli.Execute([]string{"config", "get", "region"})
+		require.Equal(t, service.NotFoundError, service.KindOf(err))
+
+		err = cli.Execute([]string{"config", "get", "api.hots"})
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+	})
+
+	t.Run("config set should write the config file", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+
+		err := cli.Execute([]string{"config", "set", "deploy.region", "us-west-2"})
+		require.Nil(t, err)
+		err = cli.Execute([]string{"config", "set", "contexts.prod.api.retry.maxAttempts", "5"})
+		require.Nil(t, err)
+
+		var saved configs.Root
+		confYaml, err := ioutil.ReadFile(cli.configFile)
+		require.Nil(t, err)
+		require.Nil(t, yaml.Unmarshal(confYaml, &saved))
+		require.Equal(t, "us-west-2", *saved.Deploy.AWSRegion)
+		require.Equal(t, 5, *saved.Contexts["prod"].API.Retry.MaxAttempts)
+		require.Equal(t, "my-api-token", *saved.API.Token)
+	})
+
+	t.Run("config set should reject invalid values", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+
+		err := cli.Execute([]string{"config", "set", "region", "eu-west-9"})
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+		require.Contains(t, err.Error(), "must be one of [us-east-1 us-east-2 us-west-1 us-west-2]")
+	})
+
+	t.Run("config unset should not remove the context in use", func(t *testing.T) {
+		cli := NewCLITest(t)
+		config := newConfig()
+		config.CurrentContext = ptr.String("prod")
+		cli.WriteConfig(t, config)
+
+		err := cli.Execute([]string{"config", "unset", "contexts.prod"})
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+
+		err = cli.Execute([]string{"config", "unset", "api.token"})
+		require.Nil(t, err)
+		var saved configs.Root
+		confYaml, err := ioutil.ReadFile(cli.configFile)
+		require.Nil(t, err)
+		require.Nil(t, yaml.Unmarshal(confYaml, &saved))
+		require.Nil(t, saved.API.Token)
+		require.Equal(t, "prod-api-token", *saved.Contexts["prod"].API.Token)
+	})
+
+	t.Run("config validate should report problems with line numbers", func(t *testing.T) {
+		cli := NewCLITest(t)
+		confFile, err := ioutil.TempFile("", "dce.*.yml")
+		require.Nil(t, err)
+		_, err = confFile.WriteString("version: 1\napi:\n  host: dce.example.com\n  hots: typo\nregion: eu-west-9\n")
+		require.Nil(t, err)
+		require.Nil(t, confFile.Close())
+
+		err = cli.Execute([]string{"config", "validate", "--config", confFile.Name()})
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+		require.Contains(t, cli.Output(), ":4: field hots not found in type configs.API")
+		require.Contains(t, cli.Output(), ":5: region: \"eu-west-9\" must be one of")
+	})
+}
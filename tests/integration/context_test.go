@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/ptr"
+	"gopkg.in/yaml.v2"
+)
+
+func TestContextCommands(t *testing.T) {
+
+	// readConfig reads the config file written by the CLI
+	readConfig := func(t *testing.T, confFile string) *configs.Root {
+		var config configs.Root
+		confYaml, err := ioutil.ReadFile(confFile)
+		require.Nil(t, err)
+		require.Nil(t, yaml.Unmarshal(confYaml, &config))
+		return &config
+	}
+
+	newConfig := func() *configs.Root {
+		return &configs.Root{
+			API: configs.API{
+				Host:     ptr.String("default.example.com"),
+				BasePath: ptr.String("/api"),
+			},
+			Contexts: map[string]*configs.Context{
+				"prod": {
+					API: configs.API{
+						Host:     ptr.String("prod.example.com"),
+						BasePath: ptr.String("/prod"),
+					},
+					Region: ptr.String("us-west-2"),
+				},
+			},
+		}
+	}
+
+	t.Run("context add should add a context", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+		defer resetFlag(t, []string{}, "api-host", "")
+		defer resetFlag(t, []string{}, "api-base-path", "")
+		defer resetFlag(t, []string{}, "region", "")
+
+		err := cli.Execute([]string{"context", "add", "dev",
+			"--api-host", "dev.example.com", "--api-base-path", "/dev", "--region", "us-east-2"})
+		require.Nil(t, err)
+
+		config := readConfig(t, cli.configFile)
+		require.Equal(t, "dev.example.com", *config.Contexts["dev"].API.Host)
+		require.Equal(t, "/dev", *config.Contexts["dev"].API.BasePath)
+		require.Equal(t, "us-east-2", *config.Contexts["dev"].Region)
+		require.Equal(t, "prod.example.com", *config.Contexts["prod"].API.Host)
+		require.Nil(t, config.CurrentContext)
+	})
+
+	t.Run("context add should fail for existing contexts", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+
+		err := cli.Execute([]string{"context", "add", "prod"})
+		require.Equal(t, service.ConflictError, service.KindOf(err))
+	})
+
+	t.Run("context use should set the current context", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+
+		err := cli.Execute([]string{"context", "use", "prod"})
+		require.Nil(t, err)
+		config := readConfig(t, cli.configFile)
+		require.Equal(t, "prod", *config.CurrentContext)
+		// Top-level settings should not be changed
+		require.Equal(t, "default.example.com", *config.API.Host)
+
+		err = cli.Execute([]string{"context", "use", "staging"})
+		require.Equal(t, service.NotFoundError, service.KindOf(err))
+	})
+
+	t.Run("context list should list all contexts", func(t *testing.T) {
+		cli := NewCLITest(t)
+		config := newConfig()
+		config.CurrentContext = ptr.String("prod")
+		cli.WriteConfig(t, config)
+
+		var printed []map[string]interface{}
+		out := &mocks.OutputWriter{}
+		out.On("Write", mock.MatchedBy(func(out []byte) bool {
+			require.Nil(t, json.Unmarshal(out, &printed))
+			return true
+		})).Return(0, nil)
+		cli.Inject(func(input *injectorInput) {
+			service.Out = out
+		})
+
+		err := cli.Execute([]string{"context", "list"})
+		require.Nil(t, err)
+		require.Equal(t, []map[string]interface{}{
+			{"name": "default", "current": false, "apiHost": "default.example.com", "apiBasePath": "/api"},
+			{"name": "prod", "current": true, "apiHost": "prod.example.com", "apiBasePath": "/prod", "region": "us-west-2"},
+		}, printed)
+	})
+
+	t.Run("context remove should remove a context", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+
+		err := cli.Execute([]string{"context", "remove", "prod"})
+		require.Nil(t, err)
+		require.Empty(t, readConfig(t, cli.configFile).Contexts)
+	})
+
+	t.Run("context remove should not remove the context in use", func(t *testing.T) {
+		cli := NewCLITest(t)
+		config := newConfig()
+		config.CurrentContext = ptr.String("prod")
+		cli.WriteConfig(t, config)
+
+		err := cli.Execute([]string{"context", "remove", "prod"})
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+	})
+
+	t.Run("auth should save the token to the active context", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+		defer resetFlag(t, []string{}, "context", "")
+
+		mockWeber := &mocks.Weber{}
+		mockWeber.On("OpenURL", "https://prod.example.com/prod/auth")
+		cli.Inject(func(input *injectorInput) {
+			input.service.Util.Weber = mockWeber
+		})
+		cli.AnswerBasic("Enter API Token: ", "prod-token")
+
+		err := cli.Execute([]string{"auth", "--context", "prod"})
+		require.Nil(t, err)
+		mockWeber.AssertExpectations(t)
+
+		config := readConfig(t, cli.configFile)
+		require.Equal(t, "prod-token", *config.Contexts["prod"].API.Token)
+		require.Equal(t, "prod.example.com", *config.Contexts["prod"].API.Host)
+		require.Nil(t, config.API.Token)
+		require.Equal(t, "default.example.com", *config.API.Host)
+	})
+
+	t.Run("--context should fail for unknown contexts", func(t *testing.T) {
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, newConfig())
+		defer resetFlag(t, []string{}, "context", "")
+
+		err := cli.Execute([]string{"context", "list", "--context", "staging"})
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+	})
+}
@@ -0,0 +1,213 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/util"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestLeasesCredentialProcess(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "dce-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(configDir)
+
+	expiresOn := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	// run executes `dce leases credential-process`, and returns the printed credentials
+	run := func(t *testing.T, args []string, api *mocks.APIer) map[string]interface{} {
+		cli := NewCLITest(t)
+
+		var printed map[string]interface{}
+		out := &mocks.OutputWriter{}
+		out.On("Write", mock.MatchedBy(func(out []byte) bool {
+			require.Nil(t, json.Unmarshal(out, &printed))
+			return true
+		})).Return(0, nil)
+
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			service.Out = out
+			input.service.Util.FileSystemer.(*util.FileSystemUtil).ConfigDir = configDir
+			input.service.Authenticater = authSvc
+		})
+
+		err := cli.Execute(args)
+		require.Nil(t, err)
+		out.AssertNumberOfCalls(t, "Write", 1)
+		return printed
+	}
+
+	t.Run("should print lease credentials in the credential_process format", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("PostLeasesIDAuth", mock.MatchedBy(func(params *operations.PostLeasesIDAuthParams) bool {
+			return params.ID == "lease-1"
+		}), nil).Return(&operations.PostLeasesIDAuthCreated{
+			Payload: &operations.PostLeasesIDAuthCreatedBody{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				ExpiresOn:       float64(expiresOn.Unix()),
+			},
+		}, nil).Once()
+
+		printed := run(t, []string{"leases", "credential-process", "lease-1"}, api)
+		require.Equal(t, map[string]interface{}{
+			"Version":         float64(1),
+			"AccessKeyId":     "access-key-id",
+			"SecretAccessKey": "secret-access-key",
+			"SessionToken":    "session-token",
+			"Expiration":      expiresOn.UTC().Format(time.RFC3339),
+		}, printed)
+		api.AssertExpectations(t)
+
+		info, err := os.Stat(filepath.Join(configDir, ".cache", "credentials", "lease-lease-1.json"))
+		require.Nil(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("should use cached credentials until they expire", func(t *testing.T) {
+		// No API calls are mocked
+		api := &mocks.APIer{}
+
+		printed := run(t, []string{"leases", "credential-process", "lease-1"}, api)
+		require.Equal(t, "access-key-id", printed["AccessKeyId"])
+		api.AssertExpectations(t)
+	})
+
+	t.Run("should refresh credentials which are about to expire", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("PostLeasesAuth", mock.Anything, nil).Return(&operations.PostLeasesAuthCreated{
+			Payload: &operations.PostLeasesAuthCreatedBody{
+				AccessKeyID:     "old-access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				ExpiresOn:       float64(time.Now().Add(time.Minute).Unix()),
+			},
+		}, nil).Once()
+		printed := run(t, []string{"leases", "credential-process"}, api)
+		require.Equal(t, "old-access-key-id", printed["AccessKeyId"])
+
+		api.On("PostLeasesAuth", mock.Anything, nil).Return(&operations.PostLeasesAuthCreated{
+			Payload: &operations.PostLeasesAuthCreatedBody{
+				AccessKeyID:     "new-access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				ExpiresOn:       float64(expiresOn.Unix()),
+			},
+		}, nil).Once()
+		printed = run(t, []string{"leases", "credential-process"}, api)
+		require.Equal(t, "new-access-key-id", printed["AccessKeyId"])
+		api.AssertExpectations(t)
+	})
+
+	t.Run("should request credentials with the lease credentials timeout", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("PostLeasesIDAuth", mock.MatchedBy(func(params *operations.PostLeasesIDAuthParams) bool {
+			deadline, ok := params.Context.Deadline()
+			remaining := time.Until(deadline)
+			return ok && remaining <= 20*time.Second && remaining > 19*time.Second
+		}), nil).Return(&operations.PostLeasesIDAuthCreated{
+			Payload: &operations.PostLeasesIDAuthCreatedBody{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				ExpiresOn:       float64(expiresOn.Unix()),
+			},
+		}, nil).Once()
+
+		run(t, []string{"leases", "credential-process", "lease-2"}, api)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("should fail without logging in, if the API credentials have expired", func(t *testing.T) {
+		cli := NewCLITest(t)
+
+		// The API rejects the request, as the API credentials have expired
+		api := &mocks.APIer{}
+		api.On("PostLeasesIDAuth", mock.Anything, nil).
+			Return(nil, &util.SigningError{Err: errors.New("credentials have expired")}).Once()
+
+		// No authentication is mocked, so logging in would fail the test
+		authSvc := &mocks.Authenticater{}
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			input.service.Util.FileSystemer.(*util.FileSystemUtil).ConfigDir = configDir
+			input.service.Authenticater = authSvc
+		})
+
+		err := cli.Execute([]string{"leases", "credential-process", "expired-lease"})
+		require.NotNil(t, err)
+		require.Equal(t, service.UnauthorizedError, service.KindOf(err))
+		api.AssertExpectations(t)
+		authSvc.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLeasesConfigureProfile(t *testing.T) {
+	awsConfig, err := ioutil.TempFile("", "aws-config")
+	require.Nil(t, err)
+	defer os.Remove(awsConfig.Name())
+	_, err = awsConfig.WriteString(strings.Join([]string{
+		"# My AWS config",
+		"[default]",
+		"region = us-west-2",
+		"",
+		"[profile dce]",
+		"credential_process = old-command",
+		"output = json",
+		"",
+	}, "\n"))
+	require.Nil(t, err)
+	require.Nil(t, awsConfig.Close())
+	os.Setenv("AWS_CONFIG_FILE", awsConfig.Name())
+	defer os.Unsetenv("AWS_CONFIG_FILE")
+
+	cli := NewCLITest(t)
+	authSvc := &mocks.Authenticater{}
+	authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+	cli.Inject(func(input *injectorInput) {
+		input.service.Authenticater = authSvc
+	})
+
+	err = cli.Execute([]string{"leases", "configure-profile", "lease-1"})
+	require.Nil(t, err)
+
+	dceBin, err := os.Executable()
+	require.Nil(t, err)
+	contents, err := ioutil.ReadFile(awsConfig.Name())
+	require.Nil(t, err)
+	require.Equal(t, strings.Join([]string{
+		"# My AWS config",
+		"[default]",
+		"region = us-west-2",
+		"",
+		"[profile dce]",
+		"credential_process = " + dceBin + " --config " + cli.configFile + " leases credential-process lease-1",
+		"output = json",
+		"",
+	}, "\n"), string(contents))
+
+	// The profile is recorded, so that `dce auth logout` removes it
+	var savedConfig configs.Root
+	confYaml, err := ioutil.ReadFile(cli.configFile)
+	require.Nil(t, err)
+	require.Nil(t, yaml.Unmarshal(confYaml, &savedConfig))
+	require.Equal(t, []string{"dce"}, savedConfig.AWSProfiles)
+}
@@ -222,6 +222,30 @@ module "dce" {
 		})
 	})
 
+	t.Run("should print the sources of deploy settings", func(t *testing.T) {
+		defer resetFlag(t, []string{}, "show-config-sources", "false")
+		deployCommandTestCase(t, &deployTestCase{
+			cliCommand: []string{"system", "deploy", "--show-config-sources", "--dce-version", "9999.12.3"},
+			yamlConfig: &configs.Root{
+				Deploy: configs.Deploy{
+					BudgetNotificationFromEmail: stringp("yaml@example.com"),
+				},
+			},
+			envVars: map[string]string{
+				"AWS_REGION":    "moon-darkside-1",
+				"DCE_NAMESPACE": "my-namespace",
+			},
+			expectedDeployedVersion: "9999.12.3",
+			expectedOutput: []string{
+				"deploy.version = 9999.12.3 (flag --dce-version)",
+				"deploy.region = moon-darkside-1 (env AWS_REGION)",
+				"deploy.namespace = my-namespace (env DCE_NAMESPACE)",
+				"deploy.budgetNotificationFromEmail = yaml@example.com (file)",
+				"deploy.location = github.com/Optum/dce (default)",
+			},
+		})
+	})
+
 	t.Run("should not prompt for approval, if batch mode is disabled", func(t *testing.T) {
 		deployCommandTestCase(t, &deployTestCase{
 			cliCommand:             []string{"system", "deploy", "--batch-mode"},
@@ -277,7 +301,7 @@ func newDeployTest(t *testing.T, config *configs.Root) *deployTest {
 
 	// Mock the Authentication service (would pop open browser to auth user)
 	authSvc := &mocks.Authenticater{}
-	authSvc.On("Authenticate").Return(nil)
+	authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
 
 	// Inject mocks as globals used by the CLI
 	cli.Inject(func(input *injectorInput) {
@@ -314,6 +338,8 @@ type deployTestCase struct {
 	expectDeploymentPrompt *bool
 	deployPromptAnswer     string
 	envVars                map[string]string
+	// List of expected strings within the command output
+	expectedOutput []string
 }
 
 // deployCommandTestCase runs a configurable integration test
@@ -413,6 +439,11 @@ func deployCommandTestCase(t *testing.T, input *deployTestCase) {
 	err := test.Execute(input.cliCommand)
 	require.Nil(t, err)
 
+	output := test.Output()
+	for _, expected := range input.expectedOutput {
+		require.Contains(t, output, expected)
+	}
+
 	// Verify that we ran terraform
 	test.terraform.AssertExpectations(t)
 	// Verify that we deployed to AWS
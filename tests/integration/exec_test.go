@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasesExec(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dce-exec")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	outFile := filepath.Join(tmpDir, "out")
+	defer mockEnvVar("OUT_FILE", outFile)()
+
+	expiresOn := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	// run executes `dce leases exec`, and returns the mock API and the error
+	run := func(t *testing.T, args []string) (*mocks.APIer, error) {
+		cli := NewCLITest(t)
+
+		api := &mocks.APIer{}
+		api.On("PostLeasesIDAuth", mock.MatchedBy(func(params *operations.PostLeasesIDAuthParams) bool {
+			return params.ID == "lease-1"
+		}), nil).Return(&operations.PostLeasesIDAuthCreated{
+			Payload: &operations.PostLeasesIDAuthCreatedBody{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				ExpiresOn:       float64(expiresOn.Unix()),
+			},
+		}, nil)
+		api.On("PostLeasesAuth", mock.Anything, nil).Return(&operations.PostLeasesAuthCreated{
+			Payload: &operations.PostLeasesAuthCreatedBody{
+				AccessKeyID:     "active-access-key-id",
+				SecretAccessKey: "active-secret-access-key",
+				SessionToken:    "active-session-token",
+			},
+		}, nil)
+
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			input.service.Authenticater = authSvc
+		})
+
+		return api, cli.Execute(append([]string{"leases", "exec"}, args...))
+	}
+
+	readOutput := func(t *testing.T) string {
+		out, err := ioutil.ReadFile(outFile)
+		require.Nil(t, err)
+		return string(out)
+	}
+
+	t.Run("should run the command with lease credentials", func(t *testing.T) {
+		defer mockEnvVar("AWS_PROFILE", "other-profile")()
+		defer mockEnvVar("DCE_REGION", "us-west-2")()
+
+		api, err := run(t, []string{"lease-1", "--", "sh", "-c", "env > $OUT_FILE"})
+		require.Nil(t, err)
+		api.AssertNotCalled(t, "PostLeasesAuth", mock.Anything, mock.Anything)
+
+		env := strings.Split(readOutput(t), "\n")
+		require.Contains(t, env, "AWS_ACCESS_KEY_ID=access-key-id")
+		require.Contains(t, env, "AWS_SECRET_ACCESS_KEY=secret-access-key")
+		require.Contains(t, env, "AWS_SESSION_TOKEN=session-token")
+		require.Contains(t, env, "AWS_CREDENTIAL_EXPIRATION="+expiresOn.UTC().Format(time.RFC3339))
+		require.Contains(t, env, "AWS_REGION=us-west-2")
+		require.Contains(t, env, "AWS_DEFAULT_REGION=us-west-2")
+		require.Contains(t, env, "OUT_FILE="+outFile)
+		for _, v := range env {
+			require.False(t, strings.HasPrefix(v, "AWS_PROFILE="), "AWS_PROFILE should not be set")
+		}
+	})
+
+	t.Run("should use the active lease, if no lease ID is provided", func(t *testing.T) {
+		_, err := run(t, []string{"--", "sh", "-c", "echo $AWS_ACCESS_KEY_ID > $OUT_FILE"})
+		require.Nil(t, err)
+		require.Equal(t, "active-access-key-id\n", readOutput(t))
+	})
+
+	t.Run("should forward stdin to the command", func(t *testing.T) {
+		stdin, err := ioutil.TempFile(tmpDir, "stdin")
+		require.Nil(t, err)
+		_, err = stdin.WriteString("hello from stdin")
+		require.Nil(t, err)
+		_, err = stdin.Seek(0, 0)
+		require.Nil(t, err)
+		origStdin := os.Stdin
+		os.Stdin = stdin
+		defer func() { os.Stdin = origStdin }()
+
+		_, err = run(t, []string{"lease-1", "--", "sh", "-c", "cat > $OUT_FILE"})
+		require.Nil(t, err)
+		require.Equal(t, "hello from stdin", readOutput(t))
+	})
+
+	t.Run("should return the exit code of the command", func(t *testing.T) {
+		_, err := run(t, []string{"lease-1", "--", "sh", "-c", "exit 42"})
+		require.NotNil(t, err)
+		exitErr, ok := err.(*service.CommandExitError)
+		require.True(t, ok, "expected a CommandExitError, got %T", err)
+		require.Equal(t, 42, exitErr.Code)
+	})
+
+	t.Run("should require a command", func(t *testing.T) {
+		_, err := run(t, []string{"lease-1"})
+		require.NotNil(t, err)
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+	})
+}
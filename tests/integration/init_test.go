@@ -45,6 +45,7 @@ func TestInitCommand(t *testing.T) {
 
 				// Check that we wrote to the dce.yml config file
 				assertYamlConfig(t, &configs.Root{
+					Version: configs.CurrentVersion,
 					API: configs.API{
 						Host:     ptr.String("dce.example.com"),
 						BasePath: ptr.String("/api"),
@@ -93,6 +94,7 @@ func TestInitCommand(t *testing.T) {
 
 				// Check that we wrote to the dce.yml config file
 				assertYamlConfig(t, &configs.Root{
+					Version: configs.CurrentVersion,
 					API: configs.API{
 						Host:     ptr.String("dce.example.com"),
 						BasePath: ptr.String("/api"),
@@ -134,6 +136,7 @@ func TestInitCommand(t *testing.T) {
 
 				// Check that YAML config was updated
 				assertYamlConfig(t, &configs.Root{
+					Version: configs.CurrentVersion,
 					API: configs.API{
 						Host: ptr.String("dce.example.com"),
 						// Should modify from CLI prompts
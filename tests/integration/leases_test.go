@@ -3,6 +3,7 @@ package integration
 import (
 	"encoding/json"
 	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/cmd"
 	"github.com/Optum/dce-cli/mocks"
 	"github.com/Optum/dce-cli/pkg/service"
 	"github.com/aws/aws-sdk-go/aws"
@@ -10,6 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -87,7 +91,7 @@ func TestLeasesEnd(t *testing.T) {
 
 			// Mock the Authentication service (would pop open browser to auth user)
 			authSvc := &mocks.Authenticater{}
-			authSvc.On("Authenticate").Return(nil)
+			authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
 
 			cli.Inject(func(input *injectorInput) {
 				service.ApiClient = api
@@ -216,7 +220,7 @@ func TestLeasesCreate(t *testing.T) {
 
 			// Mock the Authentication service (would pop open browser to auth user)
 			authSvc := &mocks.Authenticater{}
-			authSvc.On("Authenticate").Return(nil)
+			authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
 
 			cli.Inject(func(input *injectorInput) {
 				service.ApiClient = api
@@ -233,3 +237,381 @@ func TestLeasesCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestLeasesList(t *testing.T) {
+	nextLink := `<https://dce.example.com/api/leases?limit=2&nextAccountId=222&nextPrincipalId=user-2>; rel="next"`
+	page1 := []*operations.GetLeasesOKBodyItems0{
+		{ID: "lease-1", AccountID: "111", PrincipalID: "user-1"},
+		{ID: "lease-2", AccountID: "222", PrincipalID: "user-2"},
+	}
+	page2 := []*operations.GetLeasesOKBodyItems0{
+		{ID: "lease-3", AccountID: "333", PrincipalID: "user-3"},
+	}
+
+	tests := []struct {
+		name        string
+		cliInputs   cliInputs
+		pages       int
+		expectedIDs []string
+	}{
+		{
+			name:        "returns a single page by default",
+			cliInputs:   []string{"leases", "list", "--limit", "2"},
+			pages:       1,
+			expectedIDs: []string{"lease-1", "lease-2"},
+		},
+		{
+			name:        "--all follows the Link header",
+			cliInputs:   []string{"leases", "list", "--limit", "2", "--all"},
+			pages:       2,
+			expectedIDs: []string{"lease-1", "lease-2", "lease-3"},
+		},
+		{
+			name:        "--max-items caps the number of results",
+			cliInputs:   []string{"leases", "list", "--limit", "2", "--max-items", "2"},
+			pages:       1,
+			expectedIDs: []string{"lease-1", "lease-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewCLITest(t)
+
+			api := &mocks.APIer{}
+			api.On("GetLeases", mock.MatchedBy(func(params *operations.GetLeasesParams) bool {
+				return params.NextAccountID == nil || *params.NextAccountID == ""
+			}), nil).Return(&operations.GetLeasesOK{
+				Link:    nextLink,
+				Payload: page1,
+			}, nil).Once()
+			if tt.pages > 1 {
+				api.On("GetLeases", mock.MatchedBy(func(params *operations.GetLeasesParams) bool {
+					return params.NextAccountID != nil && *params.NextAccountID == "222" &&
+						*params.NextPrincipalID == "user-2"
+				}), nil).Return(&operations.GetLeasesOK{
+					Payload: page2,
+				}, nil).Once()
+			}
+
+			var actualIDs []string
+			out := &mocks.OutputWriter{}
+			out.On("Write", mock.MatchedBy(func(out []byte) bool {
+				var leases []map[string]interface{}
+				require.Nil(t, json.Unmarshal(out, &leases))
+				for _, lease := range leases {
+					actualIDs = append(actualIDs, lease["id"].(string))
+				}
+				return true
+			})).Return(0, nil)
+
+			authSvc := &mocks.Authenticater{}
+			authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+			cli.Inject(func(input *injectorInput) {
+				service.ApiClient = api
+				service.Out = out
+				input.service.Authenticater = authSvc
+			})
+
+			err := cli.Execute(tt.cliInputs)
+			require.Nil(t, err)
+
+			api.AssertExpectations(t)
+			out.AssertNumberOfCalls(t, "Write", 1)
+			require.Equal(t, tt.expectedIDs, actualIDs)
+		})
+	}
+}
+
+func TestLeasesCreateWait(t *testing.T) {
+	defer func(interval time.Duration) { service.LeaseWaitInterval = interval }(service.LeaseWaitInterval)
+	service.LeaseWaitInterval = 10 * time.Millisecond
+
+	createArgs := []string{
+		"leases", "create",
+		"-p", "test-user",
+		"-b", "100", "-c", "USD",
+		"-e", "test@example.com",
+	}
+	lease := func(status string, reason string) *operations.GetLeasesIDOK {
+		return &operations.GetLeasesIDOK{
+			Payload: &operations.GetLeasesIDOKBody{
+				ID:                "lease-1",
+				AccountID:         "123456789012",
+				PrincipalID:       "test-user",
+				LeaseStatus:       status,
+				LeaseStatusReason: reason,
+			},
+		}
+	}
+	account := func(status string) *operations.GetAccountsIDOK {
+		return &operations.GetAccountsIDOK{
+			Payload: &operations.GetAccountsIDOKBody{
+				ID:            "123456789012",
+				AccountStatus: status,
+			},
+		}
+	}
+
+	// run executes `dce leases create`, and returns the printed output, log output and error
+	run := func(t *testing.T, args []string, api *mocks.APIer) ([]string, string, error) {
+		defer resetFlag(t, []string{"leases", "create"}, "wait", "false")
+		defer resetFlag(t, []string{"leases", "create"}, "login", "false")
+		defer resetFlag(t, []string{"leases", "create"}, "print-creds", "false")
+		defer resetFlag(t, []string{"leases", "create"}, "wait-timeout", service.DefaultLeaseWaitTimeout.String())
+
+		cli := NewCLITest(t)
+		api.On("PostLeases", mock.Anything, nil).Return(&operations.PostLeasesCreated{
+			Payload: &operations.PostLeasesCreatedBody{
+				ID:          "lease-1",
+				AccountID:   "123456789012",
+				PrincipalID: "test-user",
+				LeaseStatus: "Active",
+			},
+		}, nil)
+
+		printed := []string{}
+		out := &mocks.OutputWriter{}
+		out.On("Write", mock.MatchedBy(func(out []byte) bool {
+			printed = append(printed, string(out))
+			return true
+		})).Return(0, nil)
+
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			service.Out = out
+			input.service.Authenticater = authSvc
+		})
+
+		err := cli.Execute(append(createArgs, args...))
+		return printed, cli.Output(), err
+	}
+
+	t.Run("should wait until the account is leased", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.MatchedBy(func(params *operations.GetLeasesIDParams) bool {
+			return params.ID == "lease-1"
+		}), nil).Return(lease("Active", "LeaseActive"), nil)
+		api.On("GetAccountsID", mock.MatchedBy(func(params *operations.GetAccountsIDParams) bool {
+			return params.ID == "123456789012"
+		}), nil).Return(account("NotReady"), nil).Once()
+		api.On("GetAccountsID", mock.Anything, nil).Return(account("Leased"), nil).Once()
+
+		printed, output, err := run(t, []string{"--wait"}, api)
+		require.Nil(t, err)
+		api.AssertExpectations(t)
+		require.Contains(t, output, "Lease lease-1: lease Active, account NotReady")
+		require.Contains(t, output, "Lease lease-1: lease Active, account Leased")
+		require.Contains(t, output, "Lease lease-1 is active")
+
+		require.Len(t, printed, 1)
+		var printedLease map[string]interface{}
+		require.Nil(t, json.Unmarshal([]byte(printed[0]), &printedLease))
+		require.Equal(t, "lease-1", printedLease["id"])
+		require.Equal(t, "LeaseActive", printedLease["leaseStatusReason"])
+	})
+
+	t.Run("should login once the lease is active", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.Anything, nil).Return(lease("Active", "LeaseActive"), nil)
+		// Principals may not have permission to view accounts
+		api.On("GetAccountsID", mock.Anything, nil).Return(nil, &runtime.APIError{Code: 403}).Once()
+		api.On("PostLeasesIDAuth", mock.MatchedBy(func(params *operations.PostLeasesIDAuthParams) bool {
+			// Credentials are requested with the `leases login` timeout
+			deadline, ok := params.Context.Deadline()
+			return params.ID == "lease-1" && ok && time.Until(deadline) > 19*time.Second
+		}), nil).Return(&operations.PostLeasesIDAuthCreated{
+			Payload: &operations.PostLeasesIDAuthCreatedBody{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+			},
+		}, nil)
+
+		printed, _, err := run(t, []string{"--login", "--print-creds"}, api)
+		require.Nil(t, err)
+		api.AssertExpectations(t)
+		require.Len(t, printed, 2)
+		require.Contains(t, printed[1], "access-key-id")
+	})
+
+	t.Run("should fail if the lease ends while waiting", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.Anything, nil).Return(lease("Inactive", "LeaseRolledBack"), nil)
+		api.On("GetAccountsID", mock.Anything, nil).Return(account("NotReady"), nil)
+
+		printed, _, err := run(t, []string{"--wait"}, api)
+		require.NotNil(t, err)
+		require.Equal(t, service.LeaseEndedError, service.KindOf(err))
+		require.Contains(t, err.Error(), "LeaseRolledBack")
+		require.Len(t, printed, 0)
+	})
+
+	t.Run("should time out if the account is not leased", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.Anything, nil).Return(lease("Active", "LeaseActive"), nil)
+		api.On("GetAccountsID", mock.Anything, nil).Return(account("NotReady"), nil)
+
+		printed, _, err := run(t, []string{"--wait", "--wait-timeout", "50ms"}, api)
+		require.NotNil(t, err)
+		require.Equal(t, service.TimeoutError, service.KindOf(err))
+		require.Contains(t, err.Error(), "lease lease-1 did not become active within 50ms")
+		require.Len(t, printed, 0)
+	})
+}
+
+func TestLeasesCreateFromFile(t *testing.T) {
+	specDir, err := ioutil.TempDir("", "dce-lease-spec")
+	require.Nil(t, err)
+	defer os.RemoveAll(specDir)
+	defer mockEnvVar("TEAM_EMAIL", "team@example.com")()
+
+	// run executes `dce leases create -f` with the lease spec,
+	// and returns the requested leases, each write to the output, and the error
+	run := func(t *testing.T, spec string, args ...string) ([]operations.PostLeasesBody, []string, error) {
+		// Flags set by earlier tests would override the lease spec
+		create, _, err := cmd.RootCmd.Find([]string{"leases", "create"})
+		require.Nil(t, err)
+		for _, flag := range []string{"principal-id", "budget-amount", "budget-currency", "email", "expires-on"} {
+			create.Flags().Lookup(flag).Changed = false
+		}
+		defer resetFlag(t, []string{"leases", "create"}, "file", "")
+
+		specFile := filepath.Join(specDir, "lease.yaml")
+		require.Nil(t, ioutil.WriteFile(specFile, []byte(spec), 0600))
+
+		cli := NewCLITest(t)
+		requested := []operations.PostLeasesBody{}
+		api := &mocks.APIer{}
+		api.On("PostLeases", mock.MatchedBy(func(params *operations.PostLeasesParams) bool {
+			requested = append(requested, params.Lease)
+			return true
+		}), nil).Return(func(params *operations.PostLeasesParams, _ runtime.ClientAuthInfoWriter) *operations.PostLeasesCreated {
+			return &operations.PostLeasesCreated{
+				Payload: &operations.PostLeasesCreatedBody{ID: "lease-" + *params.Lease.PrincipalID},
+			}
+		}, nil)
+
+		writes := []string{}
+		out := &mocks.OutputWriter{}
+		out.On("Write", mock.Anything).Run(func(args mock.Arguments) {
+			writes = append(writes, string(args.Get(0).([]byte)))
+		}).Return(0, nil)
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			service.Out = out
+			input.service.Authenticater = authSvc
+		})
+
+		err = cli.Execute(append([]string{"leases", "create", "-f", specFile}, args...))
+		return requested, writes, err
+	}
+
+	t.Run("should create each lease in the file", func(t *testing.T) {
+		requested, writes, err := run(t, `principalId: user-1
+budgetAmount: 100
+budgetNotificationEmails: [$TEAM_EMAIL]
+expiresOn: 1h
+---
+principalId: user-2
+budgetAmount: 50
+budgetCurrency: EUR
+budgetNotificationEmails: [user-2@example.com]
+expiresOn: 1900000000
+`)
+		require.Nil(t, err)
+		require.Len(t, requested, 2)
+
+		assert.InDelta(t, float64(time.Now().Add(time.Hour).Unix()), requested[0].ExpiresOn, 5)
+		requested[0].ExpiresOn = 0
+		require.Equal(t, operations.PostLeasesBody{
+			PrincipalID:              aws.String("user-1"),
+			BudgetAmount:             aws.Float64(100),
+			BudgetCurrency:           aws.String("USD"),
+			BudgetNotificationEmails: []string{"team@example.com"},
+		}, requested[0])
+		require.Equal(t, operations.PostLeasesBody{
+			PrincipalID:              aws.String("user-2"),
+			BudgetAmount:             aws.Float64(50),
+			BudgetCurrency:           aws.String("EUR"),
+			BudgetNotificationEmails: []string{"user-2@example.com"},
+			ExpiresOn:                1900000000,
+		}, requested[1])
+
+		// The leases are written as a single list
+		require.Len(t, writes, 1)
+		var created []map[string]interface{}
+		require.Nil(t, json.Unmarshal([]byte(writes[0]), &created))
+		require.Len(t, created, 2)
+		require.Equal(t, "lease-user-1", created[0]["id"])
+		require.Equal(t, "lease-user-2", created[1]["id"])
+	})
+
+	t.Run("should not parse YAML in env vars", func(t *testing.T) {
+		defer mockEnvVar("PRINCIPAL", "user: 1 # not a comment")()
+		requested, _, err := run(t, `principalId: $PRINCIPAL
+budgetAmount: 100
+budgetNotificationEmails: [team@example.com]
+`)
+		require.Nil(t, err)
+		require.Len(t, requested, 1)
+		require.Equal(t, "user: 1 # not a comment", *requested[0].PrincipalID)
+	})
+
+	t.Run("should override the file with flags", func(t *testing.T) {
+		requested, _, err := run(t, `{"budgetAmount": 100, "budgetNotificationEmails": ["team@example.com"]}`,
+			"--principal-id", "user-3", "--budget-amount", "25")
+		require.Nil(t, err)
+		require.Len(t, requested, 1)
+		require.Equal(t, "user-3", *requested[0].PrincipalID)
+		require.Equal(t, float64(25), *requested[0].BudgetAmount)
+	})
+
+	t.Run("should not create any leases if one is invalid", func(t *testing.T) {
+		requested, _, err := run(t, `- principalId: user-1
+  budgetAmount: 100
+  budgetNotificationEmails: [user-1@example.com]
+- budgetAmount: 100
+  budgetNotificationEmails: [not-an-email]
+  expiresOn: soon
+`)
+		require.NotNil(t, err)
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+		require.Len(t, requested, 0)
+	})
+
+	t.Run("should not login to multiple leases", func(t *testing.T) {
+		defer resetFlag(t, []string{"leases", "create"}, "login", "false")
+		requested, _, err := run(t, "[{principalId: user-1}, {principalId: user-2}]", "--login")
+		require.NotNil(t, err)
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+		require.Contains(t, err.Error(), "--login may only be used with a single lease")
+		require.Len(t, requested, 0)
+	})
+
+	t.Run("should require flags without a file", func(t *testing.T) {
+		cli := NewCLITest(t)
+		create, _, err := cmd.RootCmd.Find([]string{"leases", "create"})
+		require.Nil(t, err)
+		for _, flag := range []string{"principal-id", "budget-amount", "budget-currency", "email"} {
+			create.Flags().Lookup(flag).Changed = false
+		}
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+		cli.Inject(func(input *injectorInput) {
+			input.service.Authenticater = authSvc
+		})
+
+		err = cli.Execute([]string{"leases", "create", "-p", "user-1"})
+		require.NotNil(t, err)
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+		require.Equal(t, `required flag(s) "budget-amount", "budget-currency", "email" not set`, err.Error())
+	})
+}
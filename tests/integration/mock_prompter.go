@@ -22,6 +22,8 @@ type MockPrompter struct {
 	T             *testing.T
 	basicAnswers  []*basicAnswer
 	selectAnswers []*selectAnswer
+	// NonInteractive mocks running without a terminal
+	NonInteractive bool
 }
 
 func (m *MockPrompter) PromptBasic(label string, validator func(input string) error) *string {
@@ -39,6 +41,15 @@ func (m *MockPrompter) PromptBasic(label string, validator func(input string) er
 	return &answer.answer
 }
 
+// PromptSecret uses the same answers as PromptBasic
+func (m *MockPrompter) PromptSecret(label string) *string {
+	return m.PromptBasic(label, nil)
+}
+
+func (m *MockPrompter) IsInteractive() bool {
+	return !m.NonInteractive
+}
+
 func (m *MockPrompter) PromptSelect(label string, items []string) *string {
 	// Find a matching answer
 	var answer *selectAnswer
@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/constants"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/ptr"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigResolution(t *testing.T) {
+
+	// resolvedConfig captures the config used by commands
+	resolvedConfig := func(cli *cliTest) *configs.Root {
+		resolved := &configs.Root{}
+		cli.Inject(func(input *injectorInput) {
+			*resolved = *input.config
+		})
+		return resolved
+	}
+
+	t.Run("GIVEN no config file", func(t *testing.T) {
+
+		t.Run("THEN settings should come from env vars and flags", func(t *testing.T) {
+			cli := NewCLITest(t)
+			defer resetFlag(t, []string{}, "api-host", "")
+			defer resetFlag(t, []string{}, "api-base-path", "")
+			defer mockEnvVar("DCE_REGION", "us-west-2")()
+
+			dir, err := ioutil.TempDir("", "dce-config")
+			require.Nil(t, err)
+			defer os.RemoveAll(dir)
+			confFile := filepath.Join(dir, "config.yaml")
+
+			resolved := resolvedConfig(cli)
+			err = cli.Execute([]string{"context", "list", "--config", confFile,
+				"--api-host", "dce.example.com", "--api-base-path", "/api"})
+			require.Nil(t, err)
+
+			require.Equal(t, "dce.example.com", *resolved.API.Host)
+			require.Equal(t, "/api", *resolved.API.BasePath)
+			require.Equal(t, "us-west-2", *resolved.Region)
+			require.Equal(t, constants.DefaultDCEVersion, *resolved.Deploy.Version)
+			_, err = os.Stat(confFile)
+			require.True(t, os.IsNotExist(err))
+		})
+	})
+
+	t.Run("GIVEN a config file, env vars and flags", func(t *testing.T) {
+
+		t.Run("THEN flags should override env vars, which override the config file", func(t *testing.T) {
+			cli := NewCLITest(t)
+			cli.WriteConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("file.example.com"),
+					BasePath: ptr.String("/file"),
+				},
+				Region: ptr.String("us-east-1"),
+			})
+			defer resetFlag(t, []string{}, "api-host", "")
+			defer mockEnvVar("DCE_API_HOST", "env.example.com")()
+			defer mockEnvVar("DCE_API_BASE_PATH", "/env")()
+
+			resolved := resolvedConfig(cli)
+			err := cli.Execute([]string{"context", "list", "--api-host", "flag.example.com"})
+			require.Nil(t, err)
+
+			require.Equal(t, "flag.example.com", *resolved.API.Host)
+			require.Equal(t, "/env", *resolved.API.BasePath)
+			require.Equal(t, "us-east-1", *resolved.Region)
+		})
+
+		t.Run("THEN overrides should not be saved to the config file", func(t *testing.T) {
+			cli := NewCLITest(t)
+			cli.WriteConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("file.example.com"),
+					BasePath: ptr.String("/file"),
+				},
+			})
+			defer resetFlag(t, []string{}, "region", "")
+			defer mockEnvVar("DCE_API_HOST", "env.example.com")()
+
+			err := cli.Execute([]string{"config", "set", "deploy.region", "us-east-2", "--region", "us-west-1"})
+			require.Nil(t, err)
+
+			var saved configs.Root
+			confYaml, err := ioutil.ReadFile(cli.configFile)
+			require.Nil(t, err)
+			require.Nil(t, yaml.Unmarshal(confYaml, &saved))
+			require.Equal(t, "file.example.com", *saved.API.Host)
+			require.Equal(t, "us-east-2", *saved.Deploy.AWSRegion)
+			require.Nil(t, saved.Region)
+			require.Nil(t, saved.Deploy.Version)
+		})
+
+		t.Run("THEN invalid env vars should be rejected", func(t *testing.T) {
+			cli := NewCLITest(t)
+			defer mockEnvVar("DCE_REGION", "eu-west-9")()
+
+			err := cli.Execute([]string{"context", "list"})
+			require.NotNil(t, err)
+			require.Contains(t, err.Error(), "invalid DCE_REGION env var")
+		})
+	})
+
+	t.Run("GIVEN --show-config-sources", func(t *testing.T) {
+
+		t.Run("THEN the source of each setting should be printed", func(t *testing.T) {
+			cli := NewCLITest(t)
+			cli.WriteConfig(t, &configs.Root{
+				API: configs.API{
+					Host:     ptr.String("file.example.com"),
+					BasePath: ptr.String("/file"),
+				},
+			})
+			defer resetFlag(t, []string{}, "show-config-sources", "false")
+			defer resetFlag(t, []string{}, "token", "")
+			defer mockEnvVar("DCE_REGION", "us-west-2")()
+
+			err := cli.Execute([]string{"context", "list", "--show-config-sources", "--token", "secret-token"})
+			require.Nil(t, err)
+
+			output := cli.Output()
+			require.Contains(t, output, "api.host = file.example.com (file)")
+			require.Contains(t, output, "api.token = REDACTED (flag --token)")
+			require.Contains(t, output, "region = us-west-2 (env DCE_REGION)")
+			require.Contains(t, output, "deploy.version = "+constants.DefaultDCEVersion+" (default)")
+			require.NotContains(t, output, "secret-token")
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasesShell(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dce-shell-test")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	outFile := filepath.Join(tmpDir, "env")
+	doneFile := filepath.Join(tmpDir, "done")
+	defer mockEnvVar("OUT_FILE", outFile)()
+	defer mockEnvVar("DONE_FILE", doneFile)()
+	defer mockEnvVar("SHELL", "/bin/sh")()
+	defer mockEnvVar("PS1", "")()
+
+	credsExpireOn := time.Now().Add(time.Hour).Truncate(time.Second)
+	leaseExpiresOn := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+
+	// run executes `dce leases shell`, with the script as stdin
+	run := func(t *testing.T, script string) error {
+		cli := NewCLITest(t)
+
+		api := &mocks.APIer{}
+		api.On("PostLeasesIDAuth", mock.MatchedBy(func(params *operations.PostLeasesIDAuthParams) bool {
+			return params.ID == "lease-1"
+		}), nil).Return(&operations.PostLeasesIDAuthCreated{
+			Payload: &operations.PostLeasesIDAuthCreatedBody{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				ExpiresOn:       float64(credsExpireOn.Unix()),
+			},
+		}, nil)
+		api.On("GetLeasesID", mock.Anything, nil).Return(&operations.GetLeasesIDOK{
+			Payload: &operations.GetLeasesIDOKBody{
+				ID:        "lease-1",
+				ExpiresOn: float64(leaseExpiresOn.Unix()),
+			},
+		}, nil)
+
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			input.service.Authenticater = authSvc
+		})
+
+		stdin, err := ioutil.TempFile(tmpDir, "stdin")
+		require.Nil(t, err)
+		_, err = stdin.WriteString(script)
+		require.Nil(t, err)
+		_, err = stdin.Seek(0, 0)
+		require.Nil(t, err)
+		origStdin := os.Stdin
+		os.Stdin = stdin
+		defer func() { os.Stdin = origStdin }()
+
+		return cli.Execute([]string{"leases", "shell", "lease-1"})
+	}
+
+	t.Run("should serve lease credentials to the shell", func(t *testing.T) {
+		result := make(chan error, 1)
+		go func() {
+			// Wait for the test to request credentials, before exiting the shell
+			result <- run(t, `env > "$OUT_FILE.tmp" && mv "$OUT_FILE.tmp" "$OUT_FILE"
+while [ ! -f "$DONE_FILE" ]; do sleep 0.05; done
+exit 3
+`)
+		}()
+
+		var env map[string]string
+		require.Eventually(t, func() bool {
+			out, err := ioutil.ReadFile(outFile)
+			if err != nil {
+				return false
+			}
+			env = map[string]string{}
+			for _, line := range strings.Split(string(out), "\n") {
+				if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+					env[parts[0]] = parts[1]
+				}
+			}
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.Equal(t, "lease-1", env[service.LeaseShellEnv])
+		require.Equal(t, strconv.FormatInt(leaseExpiresOn.Unix(), 10), env[service.LeaseExpiresOnEnv])
+		require.Equal(t, "(dce:lease-1) $ ", env["PS1"])
+		require.NotContains(t, env, "AWS_ACCESS_KEY_ID")
+		require.True(t, strings.HasPrefix(env["AWS_CONTAINER_CREDENTIALS_FULL_URI"], "http://127.0.0.1:"))
+
+		req, err := http.NewRequest(http.MethodGet, env["AWS_CONTAINER_CREDENTIALS_FULL_URI"], nil)
+		require.Nil(t, err)
+		req.Header.Set("Authorization", env["AWS_CONTAINER_AUTHORIZATION_TOKEN"])
+		res, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer res.Body.Close()
+		var creds map[string]interface{}
+		require.Nil(t, json.NewDecoder(res.Body).Decode(&creds))
+		require.Equal(t, map[string]interface{}{
+			"AccessKeyId":     "access-key-id",
+			"SecretAccessKey": "secret-access-key",
+			"Token":           "session-token",
+			"Expiration":      credsExpireOn.UTC().Format(time.RFC3339),
+		}, creds)
+
+		require.Nil(t, ioutil.WriteFile(doneFile, []byte{}, 0600))
+		select {
+		case err := <-result:
+			require.NotNil(t, err)
+			exitErr, ok := err.(*service.CommandExitError)
+			require.True(t, ok, "expected a CommandExitError, got %T", err)
+			require.Equal(t, 3, exitErr.Code)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the shell to exit")
+		}
+	})
+
+	t.Run("should not start a shell within a lease shell", func(t *testing.T) {
+		defer mockEnvVar(service.LeaseShellEnv, "lease-0")()
+
+		err := run(t, "exit 0\n")
+		require.NotNil(t, err)
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+	})
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/cmd"
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITimeouts(t *testing.T) {
+
+	defaultTimeout := "10s"
+	tests := []struct {
+		name            string
+		config          *configs.Root
+		args            []string
+		expectedTimeout time.Duration
+	}{
+		{
+			name:            "should use the built-in default timeout",
+			config:          &configs.Root{},
+			args:            []string{"leases", "describe", "lease-id"},
+			expectedTimeout: service.DefaultAPITimeout,
+		},
+		{
+			name: "should use the default timeout from the config",
+			config: &configs.Root{
+				Timeouts: configs.Timeouts{Default: &defaultTimeout},
+			},
+			args:            []string{"leases", "describe", "lease-id"},
+			expectedTimeout: 10 * time.Second,
+		},
+		{
+			name: "should use the command timeout from the config",
+			config: &configs.Root{
+				Timeouts: configs.Timeouts{
+					Default:  &defaultTimeout,
+					Commands: map[string]string{"leases describe": "45s"},
+				},
+			},
+			args:            []string{"leases", "describe", "lease-id"},
+			expectedTimeout: 45 * time.Second,
+		},
+		{
+			name: "should use the --timeout flag over the config",
+			config: &configs.Root{
+				Timeouts: configs.Timeouts{Default: &defaultTimeout},
+			},
+			args:            []string{"leases", "describe", "lease-id", "--timeout", "1m"},
+			expectedTimeout: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Cobra flags persist between tests
+			defer func() {
+				require.Nil(t, cmd.RootCmd.PersistentFlags().Set("timeout", "0s"))
+			}()
+
+			cli := NewCLITest(t)
+			cli.WriteConfig(t, tt.config)
+
+			api := &mocks.APIer{}
+			api.On("GetLeasesID", mock.MatchedBy(func(params *operations.GetLeasesIDParams) bool {
+				deadline, ok := params.Context.Deadline()
+				remaining := time.Until(deadline)
+				return ok && remaining <= tt.expectedTimeout && remaining > tt.expectedTimeout-time.Second
+			}), nil).Return(&operations.GetLeasesIDOK{
+				Payload: &operations.GetLeasesIDOKBody{ID: "lease-id"},
+			}, nil)
+
+			out := &mocks.OutputWriter{}
+			out.On("Write", mock.Anything).Return(0, nil)
+
+			authSvc := &mocks.Authenticater{}
+			authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+			cli.Inject(func(input *injectorInput) {
+				service.ApiClient = api
+				service.Out = out
+				input.service.Authenticater = authSvc
+			})
+
+			err := cli.Execute(tt.args)
+			require.Nil(t, err)
+			api.AssertExpectations(t)
+		})
+	}
+
+	t.Run("should reject an invalid timeout in the config", func(t *testing.T) {
+		invalidTimeout := "soon"
+		cli := NewCLITest(t)
+		cli.WriteConfig(t, &configs.Root{
+			Timeouts: configs.Timeouts{Default: &invalidTimeout},
+		})
+
+		err := cli.Execute([]string{"leases", "describe", "lease-id"})
+		require.NotNil(t, err)
+		require.Equal(t, service.ValidationError, service.KindOf(err))
+	})
+}
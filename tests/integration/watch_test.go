@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasesWatch(t *testing.T) {
+	createdOn := time.Now().Add(-time.Hour)
+	expiresOn := time.Now().Add(49*time.Hour + 30*time.Second)
+
+	lease := func(status string, reason string) *operations.GetLeasesIDOK {
+		return &operations.GetLeasesIDOK{
+			Payload: &operations.GetLeasesIDOKBody{
+				ID:                "lease-1",
+				AccountID:         "123456789012",
+				PrincipalID:       "user-1",
+				BudgetAmount:      100,
+				BudgetCurrency:    "USD",
+				CreatedOn:         float64(createdOn.Unix()),
+				ExpiresOn:         float64(expiresOn.Unix()),
+				LeaseStatus:       status,
+				LeaseStatusReason: reason,
+			},
+		}
+	}
+	usageFor := func(accountID string, cost float64) *operations.GetUsageOK {
+		return &operations.GetUsageOK{
+			Payload: &operations.GetUsageOKBody{
+				AccountID:    accountID,
+				PrincipalID:  "user-1",
+				CostAmount:   cost,
+				CostCurrency: "USD",
+			},
+		}
+	}
+	usage := func(cost float64) *operations.GetUsageOK {
+		return usageFor("123456789012", cost)
+	}
+
+	// run executes `dce leases watch`, and returns the log output and the error
+	run := func(t *testing.T, api *mocks.APIer) (string, error) {
+		cli := NewCLITest(t)
+
+		authSvc := &mocks.Authenticater{}
+		authSvc.On("Authenticate", mock.Anything, mock.Anything).Return(nil)
+
+		cli.Inject(func(input *injectorInput) {
+			service.ApiClient = api
+			input.service.Authenticater = authSvc
+		})
+
+		err := cli.Execute([]string{"leases", "watch", "lease-1", "--interval", "10ms"})
+		return cli.Output(), err
+	}
+
+	t.Run("should log status changes, and fail when the lease expires", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.MatchedBy(func(params *operations.GetLeasesIDParams) bool {
+			return params.ID == "lease-1"
+		}), nil).Return(lease("Active", "LeaseActive"), nil).Once()
+		api.On("GetLeasesID", mock.Anything, nil).
+			Return(lease("Inactive", "LeaseExpired"), nil).Once()
+		api.On("GetUsage", mock.MatchedBy(func(params *operations.GetUsageParams) bool {
+			return params.StartDate == float64(createdOn.Unix())
+		}), nil).Return(usage(25.5), nil)
+
+		output, err := run(t, api)
+		require.NotNil(t, err)
+		require.Equal(t, service.LeaseEndedError, service.KindOf(err))
+		require.Contains(t, err.Error(), "lease lease-1 expired")
+		require.Contains(t, output, "Lease lease-1 is Active: 74.50 of 100.00 USD budget remaining, expires in 2d 1h 0m")
+		require.Contains(t, output, "Lease lease-1 status changed from Active to Inactive (LeaseExpired)")
+		api.AssertExpectations(t)
+	})
+
+	t.Run("should fail when the lease is over budget", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.Anything, nil).Return(lease("Active", "LeaseActive"), nil)
+		api.On("GetUsage", mock.Anything, nil).Return(usage(10), nil).Once()
+		api.On("GetUsage", mock.Anything, nil).Return(usage(100.25), nil).Once()
+
+		output, err := run(t, api)
+		require.NotNil(t, err)
+		require.Equal(t, service.LeaseOverBudgetError, service.KindOf(err))
+		require.Contains(t, err.Error(), "spent 100.25 of 100.00 USD")
+		require.Contains(t, output, "Lease lease-1 is Active: -0.25 of 100.00 USD budget remaining")
+		api.AssertExpectations(t)
+	})
+
+	t.Run("should not count the usage of other accounts", func(t *testing.T) {
+		for _, accountID := range []string{"999999999999", ""} {
+			api := &mocks.APIer{}
+			api.On("GetLeasesID", mock.Anything, nil).Return(lease("Active", "LeaseActive"), nil).Once()
+			api.On("GetLeasesID", mock.Anything, nil).Return(lease("Inactive", "LeaseExpired"), nil).Once()
+			api.On("GetUsage", mock.Anything, nil).Return(usageFor(accountID, 150), nil)
+
+			output, err := run(t, api)
+			require.Equal(t, service.LeaseEndedError, service.KindOf(err), accountID)
+			require.Contains(t, output, "Lease lease-1 is Active: 100.00 of 100.00 USD budget remaining", accountID)
+		}
+	})
+
+	t.Run("should keep watching through network errors", func(t *testing.T) {
+		api := &mocks.APIer{}
+		api.On("GetLeasesID", mock.Anything, nil).
+			Return(nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}).Once()
+		api.On("GetLeasesID", mock.Anything, nil).Return(lease("Inactive", "LeaseDestroyed"), nil).Once()
+		api.On("GetUsage", mock.Anything, nil).Return(usage(0), nil)
+
+		output, err := run(t, api)
+		require.NotNil(t, err)
+		require.Equal(t, service.LeaseEndedError, service.KindOf(err))
+		require.Contains(t, err.Error(), "lease lease-1 is no longer active (LeaseDestroyed)")
+		require.Contains(t, output, "Failed to get lease: dial: connection refused. Retrying in 10ms")
+		api.AssertExpectations(t)
+	})
+}
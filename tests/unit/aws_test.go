@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureAWSCLICredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aws")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	credsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+	defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+	os.Setenv("AWS_CONFIG_FILE", configFile)
+	defer os.Unsetenv("AWS_CONFIG_FILE")
+
+	awsUtil := &util.AWSUtil{Config: &configs.Root{}}
+	expiration := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("should create the credentials and config files", func(t *testing.T) {
+		err := awsUtil.ConfigureAWSCLICredentials(&util.AWSCLICredentials{
+			Profile:         "default",
+			AccessKeyID:     "key",
+			SecretAccessKey: "secret",
+			SessionToken:    "token",
+			Expiration:      expiration,
+			Region:          "us-east-1",
+			LeaseID:         "lease-1",
+		})
+		require.Nil(t, err)
+
+		requireFileContents(t, credsFile, 0600,
+			"[default]",
+			"aws_access_key_id = key",
+			"aws_secret_access_key = secret",
+			"aws_session_token = token",
+			"dce_lease_id = lease-1",
+			"expiration = 2020-01-02T03:04:05Z",
+		)
+		requireFileContents(t, configFile, 0600,
+			"[default]",
+			"region = us-east-1",
+		)
+	})
+
+	t.Run("should update a profile, preserving comments and other profiles", func(t *testing.T) {
+		require.Nil(t, ioutil.WriteFile(credsFile, []byte(strings.Join([]string{
+			"# My credentials",
+			"[personal]",
+			"aws_access_key_id = personal-key",
+			"",
+			"[dce]",
+			"# Written by dce",
+			"aws_access_key_id = old-key",
+			"aws_secret_access_key = old-secret",
+			"dce_lease_id = old-lease",
+			"",
+			"[work]",
+			"aws_access_key_id = work-key",
+			"",
+		}, "\n")), 0640))
+		require.Nil(t, ioutil.WriteFile(configFile, []byte(strings.Join([]string{
+			"[profile dce]",
+			"output = json",
+			"",
+		}, "\n")), 0644))
+		require.Nil(t, os.Chmod(credsFile, 0640))
+		require.Nil(t, os.Chmod(configFile, 0644))
+
+		err := awsUtil.ConfigureAWSCLICredentials(&util.AWSCLICredentials{
+			Profile:         "dce",
+			AccessKeyID:     "new-key",
+			SecretAccessKey: "new-secret",
+			SessionToken:    "new-token",
+			Region:          "us-west-2",
+		})
+		require.Nil(t, err)
+
+		// Unknown expiration and lease ID should be removed
+		requireFileContents(t, credsFile, 0640,
+			"# My credentials",
+			"[personal]",
+			"aws_access_key_id = personal-key",
+			"",
+			"[dce]",
+			"# Written by dce",
+			"aws_access_key_id = new-key",
+			"aws_secret_access_key = new-secret",
+			"aws_session_token = new-token",
+			"",
+			"[work]",
+			"aws_access_key_id = work-key",
+		)
+		requireFileContents(t, configFile, 0644,
+			"[profile dce]",
+			"output = json",
+			"region = us-west-2",
+		)
+
+		// No temp files should be left behind
+		files, err := ioutil.ReadDir(dir)
+		require.Nil(t, err)
+		require.Len(t, files, 2)
+	})
+}
+
+// requireFileContents checks the lines and permissions of a file
+func requireFileContents(t *testing.T, path string, mode os.FileMode, lines ...string) {
+	contents, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, strings.Join(lines, "\n")+"\n", string(contents))
+
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	require.Equal(t, mode, info.Mode().Perm())
+}
@@ -42,6 +42,7 @@ var mockTFTemplater mocks.TFTemplater
 var mockAPIer mocks.APIer
 var spyLogger TestLogObservation
 var mockOutputWriter mocks.OutputWriter
+var mockCredentialStore mocks.CredentialStore
 var service *svc.ServiceContainer
 
 func initMocks(config configs.Root) {
@@ -54,6 +55,7 @@ func initMocks(config configs.Root) {
 	mockAPIer = mocks.APIer{}
 	mockTFTemplater = mocks.TFTemplater{}
 	mockOutputWriter = mocks.OutputWriter{}
+	mockCredentialStore = mocks.CredentialStore{}
 	spyLogger = TestLogObservation{
 		logrus.New(),
 		false,
@@ -64,16 +66,17 @@ func initMocks(config configs.Root) {
 		OutputWriter: &mockOutputWriter,
 	}
 	mockUtil := utl.UtilContainer{
-		Config:       &config,
-		Prompter:     &mockPrompter,
-		FileSystemer: &mockFileSystemer,
-		Weber:        &mockWeber,
-		Observation:  &spyObservation,
-		Githuber:     &mockGithuber,
-		AWSer:        &mockAwser,
-		Terraformer:  &mockTerraformer,
-		APIer:        &mockAPIer,
-		TFTemplater:  &mockTFTemplater,
+		Config:          &config,
+		Prompter:        &mockPrompter,
+		FileSystemer:    &mockFileSystemer,
+		Weber:           &mockWeber,
+		Observation:     &spyObservation,
+		Githuber:        &mockGithuber,
+		AWSer:           &mockAwser,
+		Terraformer:     &mockTerraformer,
+		APIer:           &mockAPIer,
+		TFTemplater:     &mockTFTemplater,
+		CredentialStore: &mockCredentialStore,
 	}
 	service = svc.New(&config, &spyObservation, &mockUtil)
 }
@@ -1,7 +1,10 @@
 package unit
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	cfg "github.com/Optum/dce-cli/configs"
@@ -85,3 +88,313 @@ func TestCoalesce_WithNils(t *testing.T) {
 		t.Errorf("Coalesce() = %v, want %v", got, expected)
 	}
 }
+
+func TestUseContext(t *testing.T) {
+	str := func(s string) *string { return &s }
+	newConfig := func() *cfg.Root {
+		return &cfg.Root{
+			API:    cfg.API{Host: str("default.example.com"), BasePath: str("/api")},
+			Region: str("us-east-1"),
+			Contexts: map[string]*cfg.Context{
+				"prod": {
+					API:    cfg.API{Host: str("prod.example.com"), BasePath: str("/prod")},
+					Region: str("us-west-2"),
+				},
+				"dev": {
+					API: cfg.API{Host: str("dev.example.com")},
+				},
+			},
+		}
+	}
+
+	t.Run("should use the context settings", func(t *testing.T) {
+		config := newConfig()
+		if err := config.UseContext("prod"); err != nil {
+			t.Fatal(err)
+		}
+		if *config.API.Host != "prod.example.com" || *config.Region != "us-west-2" {
+			t.Errorf("expected prod settings, got host %s, region %s", *config.API.Host, *config.Region)
+		}
+		if config.ActiveContext() != "prod" {
+			t.Errorf("expected active context prod, got %s", config.ActiveContext())
+		}
+	})
+
+	t.Run("should save changes to the active context", func(t *testing.T) {
+		config := newConfig()
+		if err := config.UseContext("prod"); err != nil {
+			t.Fatal(err)
+		}
+		config.API.Token = str("prod-token")
+
+		file := config.ForFile()
+		if file.API.Token != nil || *file.API.Host != "default.example.com" {
+			t.Errorf("expected the top-level settings to be unchanged")
+		}
+		if *file.Contexts["prod"].API.Token != "prod-token" {
+			t.Errorf("expected the token to be saved to the prod context")
+		}
+		if config.Contexts["prod"].API.Token != nil {
+			t.Errorf("ForFile should not modify the config")
+		}
+	})
+
+	t.Run("should switch between contexts", func(t *testing.T) {
+		config := newConfig()
+		if err := config.UseContext("prod"); err != nil {
+			t.Fatal(err)
+		}
+		config.API.Token = str("prod-token")
+		if err := config.UseContext("dev"); err != nil {
+			t.Fatal(err)
+		}
+		if *config.API.Host != "dev.example.com" || config.Region != nil {
+			t.Errorf("expected dev settings")
+		}
+		if err := config.UseContext(cfg.DefaultContext); err != nil {
+			t.Fatal(err)
+		}
+		if *config.API.Host != "default.example.com" {
+			t.Errorf("expected default settings")
+		}
+		if *config.Contexts["prod"].API.Token != "prod-token" {
+			t.Errorf("expected prod context changes to be kept")
+		}
+	})
+
+	t.Run("should fail for unknown contexts", func(t *testing.T) {
+		config := newConfig()
+		if err := config.UseContext("staging"); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+func TestConfigSettings(t *testing.T) {
+
+	t.Run("should set and get settings by YAML key", func(t *testing.T) {
+		config := &cfg.Root{}
+		settings := map[string]string{
+			"api.host":                               "dce.example.com",
+			"api.basePath":                           "/api",
+			"region":                                 "us-east-2",
+			"deploy.region":                          "us-west-1",
+			"terraform.initOptions":                  "-no-color",
+			"api.retry.maxAttempts":                  "5",
+			"timeouts.commands.leases list":          "30s",
+			"contexts.prod.api.host":                 "prod.example.com",
+			"contexts.prod.api.credentialStore.type": "file",
+		}
+		for key, val := range settings {
+			if err := config.Set(key, val); err != nil {
+				t.Fatalf("Set(%s) failed: %s", key, err)
+			}
+		}
+		for key, want := range settings {
+			got, err := config.Get(key)
+			if err != nil {
+				t.Fatalf("Get(%s) failed: %s", key, err)
+			}
+			if fmt.Sprint(got) != want {
+				t.Errorf("Get(%s) = %v, want %v", key, got, want)
+			}
+		}
+		if *config.Deploy.AWSRegion != "us-west-1" || *config.API.Retry.MaxAttempts != 5 {
+			t.Errorf("expected settings to be set on the config")
+		}
+		if config.Timeouts.Commands["leases list"] != "30s" {
+			t.Errorf("expected timeouts.commands to be set")
+		}
+	})
+
+	t.Run("should set lists", func(t *testing.T) {
+		config := &cfg.Root{}
+		if err := config.Set("aws.roleArns", "arn:aws:iam::1:role/a, arn:aws:iam::1:role/b"); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(config.AWS.RoleARNs, []string{"arn:aws:iam::1:role/a", "arn:aws:iam::1:role/b"}) {
+			t.Errorf("unexpected roleArns %v", config.AWS.RoleARNs)
+		}
+	})
+
+	t.Run("should reject invalid values", func(t *testing.T) {
+		tests := map[string]string{
+			"region":                 "eu-west-9",
+			"contexts.prod.region":   "eu-west-9",
+			"api.authMode":           "carrier-pigeon",
+			"api.retry.maxAttempts":  "lots",
+			"timeouts.default":       "soon",
+			"timeouts.commands.auth": "-5s",
+			"api.bogus":              "true",
+			"api":                    "dce.example.com",
+			"region.name":            "us-east-1",
+		}
+		for key, val := range tests {
+			config := &cfg.Root{}
+			if err := config.Set(key, val); err == nil {
+				t.Errorf("Set(%s, %s) should fail", key, val)
+			}
+		}
+	})
+
+	t.Run("should unset settings", func(t *testing.T) {
+		str := func(s string) *string { return &s }
+		config := &cfg.Root{
+			API:      cfg.API{Host: str("dce.example.com")},
+			Timeouts: cfg.Timeouts{Commands: map[string]string{"leases list": "30s"}},
+			Contexts: map[string]*cfg.Context{"prod": {Region: str("us-east-1")}},
+		}
+		for _, key := range []string{"api.host", "timeouts.commands.leases list", "contexts.prod", "contexts.dev.region"} {
+			if err := config.Unset(key); err != nil {
+				t.Fatalf("Unset(%s) failed: %s", key, err)
+			}
+		}
+		if config.API.Host != nil || len(config.Timeouts.Commands) != 0 || len(config.Contexts) != 0 {
+			t.Errorf("expected settings to be removed, got %+v", config)
+		}
+	})
+}
+
+func TestValidateConfig(t *testing.T) {
+	data := strings.Join([]string{
+		"api:",
+		"  host: dce.example.com",
+		"  authMode: carrier-pigeon",
+		"region: us-east-1",
+		"contexts:",
+		"  prod:",
+		"    region: eu-west-9",
+		"timeouts:",
+		"  commands:",
+		"    leases list: soon",
+		"currentContext: staging",
+		"",
+	}, "\n")
+
+	problems := cfg.Validate([]byte(data))
+	got := []string{}
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	want := []string{
+		`line 3: api.authMode: "carrier-pigeon" must be one of [paste loopback]`,
+		`line 7: contexts.prod.region: "eu-west-9" must be one of [us-east-1 us-east-2 us-west-1 us-west-2]`,
+		`line 10: timeouts.commands.leases list: "soon" is not a duration (eg. "10s")`,
+		`line 11: currentContext: context "staging" not found. Must be one of [default prod]`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %#v, want %#v", got, want)
+	}
+
+	t.Run("should report unknown keys and invalid types", func(t *testing.T) {
+		problems := cfg.Validate([]byte("api:\n  host: dce.example.com\n  hots: typo\nregion: [us-east-1]\n"))
+		if len(problems) != 2 || problems[0].Line != 3 || problems[1].Line != 4 {
+			t.Errorf("unexpected problems %v", problems)
+		}
+	})
+
+	t.Run("should pass valid config", func(t *testing.T) {
+		problems := cfg.Validate([]byte("api:\n  host: dce.example.com\nregion: us-east-1\n"))
+		if len(problems) != 0 {
+			t.Errorf("unexpected problems %v", problems)
+		}
+	})
+}
+
+func TestEnvVar(t *testing.T) {
+	tests := map[string]string{
+		"api.host":                 "DCE_API_HOST",
+		"api.basepath":             "DCE_API_BASE_PATH",
+		"api.token":                "DCE_API_TOKEN",
+		"region":                   "DCE_REGION",
+		"api.retry.maxAttempts":    "DCE_API_RETRY_MAX_ATTEMPTS",
+		"api.credentialStore.type": "DCE_API_CREDENTIAL_STORE_TYPE",
+		"aws.roleArns":             "DCE_AWS_ROLE_ARNS",
+		"deploy.region":            "DCE_DEPLOY_REGION",
+		"deploy.version":           "DCE_VERSION",
+		"terraform.initOptions":    "DCE_TF_INIT_OPTIONS",
+		"terraform.bin":            "DCE_TERRAFORM_BIN",
+	}
+	for key, want := range tests {
+		if got := cfg.EnvVar(key); got != want {
+			t.Errorf("EnvVar(%s) = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	t.Run("should not save overrides to the config file", func(t *testing.T) {
+		config := &cfg.Root{API: cfg.API{Host: str("file.example.com")}}
+		if err := config.Override("api.host", "flag.example.com", cfg.SourceFlag, "--api-host"); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.Override("region", "us-west-2", cfg.SourceEnv, "DCE_REGION"); err != nil {
+			t.Fatal(err)
+		}
+		if *config.API.Host != "flag.example.com" || *config.Region != "us-west-2" {
+			t.Errorf("expected overrides to be applied")
+		}
+		file := config.ForFile()
+		if *file.API.Host != "file.example.com" || file.Region != nil {
+			t.Errorf("expected overrides to be removed, got %+v", file)
+		}
+		if *config.API.Host != "flag.example.com" {
+			t.Errorf("ForFile should not modify the config")
+		}
+	})
+
+	t.Run("should save values changed by commands", func(t *testing.T) {
+		config := &cfg.Root{}
+		if err := config.Override("api.token", "env-token", cfg.SourceEnv, "DCE_API_TOKEN"); err != nil {
+			t.Fatal(err)
+		}
+		config.API.Token = str("env-token")
+		if file := config.ForFile(); file.API.Token == nil || *file.API.Token != "env-token" {
+			t.Errorf("expected the new token to be saved")
+		}
+		if config.IsOverridden("api.token") {
+			t.Errorf("expected the token not to be overridden")
+		}
+	})
+
+	t.Run("should save overrides to the active context", func(t *testing.T) {
+		config := &cfg.Root{
+			API:      cfg.API{Host: str("default.example.com")},
+			Contexts: map[string]*cfg.Context{"prod": {API: cfg.API{Host: str("prod.example.com")}}},
+		}
+		if err := config.UseContext("prod"); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.Override("api.host", "flag.example.com", cfg.SourceFlag, "--api-host"); err != nil {
+			t.Fatal(err)
+		}
+		config.API.BasePath = str("/prod")
+		file := config.ForFile()
+		if *file.API.Host != "default.example.com" || *file.Contexts["prod"].API.Host != "prod.example.com" {
+			t.Errorf("expected overrides to be removed")
+		}
+		if *file.Contexts["prod"].API.BasePath != "/prod" {
+			t.Errorf("expected changes to be saved to the prod context")
+		}
+	})
+
+	t.Run("should apply defaults to settings which are not set", func(t *testing.T) {
+		config := &cfg.Root{Deploy: cfg.Deploy{Version: str("1.0.0")}}
+		err := config.ApplyDefaults(&cfg.Root{Deploy: cfg.Deploy{Version: str("2.0.0"), Location: str("github.com/Optum/dce")}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *config.Deploy.Version != "1.0.0" || *config.Deploy.Location != "github.com/Optum/dce" {
+			t.Errorf("unexpected deploy config %+v", config.Deploy)
+		}
+		sources := map[string]string{}
+		for _, s := range config.Sources() {
+			sources[s.Key] = s.Source
+		}
+		if sources["deploy.version"] != cfg.SourceFile || sources["deploy.location"] != cfg.SourceDefault {
+			t.Errorf("unexpected sources %v", sources)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	util "github.com/Optum/dce-cli/internal/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialServer(t *testing.T) {
+
+	// get requests credentials from the server, as an AWS SDK would
+	get := func(t *testing.T, server *util.CredentialServer, token string) (int, map[string]interface{}) {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.Nil(t, err)
+		req.Header.Set("Authorization", token)
+		res, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer res.Body.Close()
+
+		var body map[string]interface{}
+		require.Nil(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body
+	}
+	credentials := func(key string, expiration time.Time) *util.ContainerCredentials {
+		return &util.ContainerCredentials{
+			AccessKeyID:     key,
+			SecretAccessKey: "secret-" + key,
+			Token:           "token-" + key,
+			Expiration:      expiration.UTC().Truncate(time.Second),
+		}
+	}
+	noRefresh := func(t *testing.T) func() (*util.ContainerCredentials, error) {
+		return func() (*util.ContainerCredentials, error) {
+			t.Error("credentials should not be refreshed")
+			return nil, errors.New("unexpected refresh")
+		}
+	}
+
+	t.Run("should serve credentials on localhost", func(t *testing.T) {
+		expiration := time.Now().Add(time.Hour)
+		server, err := util.StartCredentialServer(credentials("key-1", expiration), 15*time.Minute, noRefresh(t))
+		require.Nil(t, err)
+		defer server.Close()
+
+		serverURL, err := url.Parse(server.URL)
+		require.Nil(t, err)
+		require.Equal(t, "127.0.0.1", serverURL.Hostname())
+
+		status, body := get(t, server, server.AuthToken)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]interface{}{
+			"AccessKeyId":     "key-1",
+			"SecretAccessKey": "secret-key-1",
+			"Token":           "token-key-1",
+			"Expiration":      expiration.UTC().Truncate(time.Second).Format(time.RFC3339),
+		}, body)
+	})
+
+	t.Run("should reject requests without the auth token", func(t *testing.T) {
+		server, err := util.StartCredentialServer(credentials("key-1", time.Now().Add(time.Hour)), 15*time.Minute, noRefresh(t))
+		require.Nil(t, err)
+		defer server.Close()
+
+		status, body := get(t, server, "wrong-token")
+		require.Equal(t, http.StatusUnauthorized, status)
+		require.Nil(t, body["AccessKeyId"])
+	})
+
+	t.Run("should refresh credentials which are about to expire", func(t *testing.T) {
+		refreshes := 0
+		server, err := util.StartCredentialServer(credentials("key-1", time.Now().Add(10*time.Minute)), 15*time.Minute,
+			func() (*util.ContainerCredentials, error) {
+				refreshes++
+				return credentials("key-2", time.Now().Add(time.Hour)), nil
+			})
+		require.Nil(t, err)
+		defer server.Close()
+
+		for i := 0; i < 2; i++ {
+			status, body := get(t, server, server.AuthToken)
+			require.Equal(t, http.StatusOK, status)
+			require.Equal(t, "key-2", body["AccessKeyId"])
+		}
+		require.Equal(t, 1, refreshes)
+	})
+
+	t.Run("should serve the current credentials until they expire, if refreshing fails", func(t *testing.T) {
+		failRefresh := func() (*util.ContainerCredentials, error) {
+			return nil, errors.New("refresh failed")
+		}
+		server, err := util.StartCredentialServer(credentials("key-1", time.Now().Add(10*time.Minute)), 15*time.Minute, failRefresh)
+		require.Nil(t, err)
+		defer server.Close()
+
+		status, body := get(t, server, server.AuthToken)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "key-1", body["AccessKeyId"])
+
+		expired, err := util.StartCredentialServer(credentials("key-1", time.Now().Add(-time.Minute)), 15*time.Minute, failRefresh)
+		require.Nil(t, err)
+		defer expired.Close()
+
+		status, body = get(t, expired, expired.AuthToken)
+		require.Equal(t, http.StatusInternalServerError, status)
+		require.Equal(t, "failed to refresh credentials: refresh failed", body["message"])
+	})
+}
@@ -0,0 +1,208 @@
+package unit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Optum/dce-cli/configs"
+	util "github.com/Optum/dce-cli/internal/util"
+	"github.com/Optum/dce-cli/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedFileCredentialStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dce-credstore")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token.enc")
+
+	passphrase := func(val string) func() (string, error) {
+		return func() (string, error) { return val, nil }
+	}
+
+	t.Run("should store and read back the token", func(t *testing.T) {
+		store := &util.EncryptedFileCredentialStore{Path: path, Passphrase: passphrase("secret")}
+		require.Nil(t, store.StoreToken("my-token"))
+
+		contents, err := ioutil.ReadFile(path)
+		require.Nil(t, err)
+		require.NotContains(t, string(contents), "my-token")
+		info, err := os.Stat(path)
+		require.Nil(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		// Read with a new store, so the token isn't cached
+		store = &util.EncryptedFileCredentialStore{Path: path, Passphrase: passphrase("secret")}
+		token, err := store.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, "my-token", *token)
+	})
+
+	t.Run("should fail with the wrong passphrase", func(t *testing.T) {
+		store := &util.EncryptedFileCredentialStore{Path: path, Passphrase: passphrase("wrong")}
+		_, err := store.GetToken()
+		require.NotNil(t, err)
+	})
+
+	t.Run("should ask for the passphrase again, if it was incorrect", func(t *testing.T) {
+		passphrases := []string{"wrong", "secret"}
+		calls := 0
+		store := &util.EncryptedFileCredentialStore{Path: path, Passphrase: func() (string, error) {
+			calls++
+			return passphrases[calls-1], nil
+		}}
+
+		_, err := store.GetToken()
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "incorrect passphrase")
+
+		token, err := store.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, "my-token", *token)
+
+		// The correct passphrase is remembered
+		_, err = store.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("should erase the token", func(t *testing.T) {
+		store := &util.EncryptedFileCredentialStore{Path: path, Passphrase: passphrase("secret")}
+		require.Nil(t, store.EraseToken())
+		token, err := store.GetToken()
+		require.Nil(t, err)
+		require.Nil(t, token)
+	})
+}
+
+func TestHelperCredentialStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper test script requires a POSIX shell")
+	}
+	dir, err := ioutil.TempDir("", "dce-credstore")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Mock credential helper, which keeps the secret in a file
+	secretFile := filepath.Join(dir, "secret")
+	helper := filepath.Join(dir, "docker-credential-test")
+	script := `#!/bin/sh
+case "$1" in
+  store) sed 's/.*"Secret":"\([^"]*\)".*/\1/' > ` + secretFile + ` ;;
+  get)
+    if [ ! -f ` + secretFile + ` ]; then
+      echo "credentials not found in native keychain"; exit 1
+    fi
+    printf '{"ServerURL":"dce","Username":"dce","Secret":"%s"}' "$(cat ` + secretFile + `)" ;;
+  erase) rm -f ` + secretFile + ` ;;
+esac
+`
+	require.Nil(t, ioutil.WriteFile(helper, []byte(script), 0700))
+
+	store := &util.HelperCredentialStore{Helper: helper, ServerURL: "dce"}
+
+	token, err := store.GetToken()
+	require.Nil(t, err, "missing credentials should not be an error")
+	require.Nil(t, token)
+
+	require.Nil(t, store.StoreToken("my-token"))
+	token, err = store.GetToken()
+	require.Nil(t, err)
+	require.Equal(t, "my-token", *token)
+
+	require.Nil(t, store.EraseToken())
+	token, err = store.GetToken()
+	require.Nil(t, err)
+	require.Nil(t, token)
+}
+
+func TestNewCredentialStore(t *testing.T) {
+	storeType := func(val string) *configs.Root {
+		config := &configs.Root{}
+		config.API.CredentialStore.Type = &val
+		return config
+	}
+	passphrase := func() (string, error) { return "", nil }
+
+	t.Run("should default to plaintext", func(t *testing.T) {
+		store, err := util.NewCredentialStore(&configs.Root{}, &util.FileSystemUtil{}, passphrase)
+		require.Nil(t, err)
+		require.IsType(t, &util.PlaintextCredentialStore{}, store)
+	})
+
+	t.Run("should require a helper for the helper store", func(t *testing.T) {
+		_, err := util.NewCredentialStore(storeType(util.CredentialStoreHelper), &util.FileSystemUtil{}, passphrase)
+		require.NotNil(t, err)
+	})
+
+	t.Run("should reject invalid types", func(t *testing.T) {
+		_, err := util.NewCredentialStore(storeType("keyring"), &util.FileSystemUtil{}, passphrase)
+		require.Contains(t, err.Error(), "invalid api.credentialStore.type")
+	})
+}
+
+func TestPlaintextTokenMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dce-credstore")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token.enc")
+	passphrase := func() (string, error) { return "secret", nil }
+
+	// newStore returns an encrypted file store, for a config which
+	// was used with the plaintext store
+	newStore := func(t *testing.T, plaintext string) (util.CredentialStore, *configs.Root, *mocks.FileSystemer) {
+		config := &configs.Root{}
+		config.API.Token = &plaintext
+		storeType := util.CredentialStoreFile
+		config.API.CredentialStore.Type = &storeType
+		config.API.CredentialStore.File = &path
+
+		fs := &mocks.FileSystemer{}
+		fs.On("WriteConfig").Return(nil)
+		fs.On("GetConfigDir").Return(dir)
+		fs.On("GetConfigFile").Return(filepath.Join(dir, "config.yaml"))
+		store, err := util.NewCredentialStore(config, fs, passphrase)
+		require.Nil(t, err)
+		return store, config, fs
+	}
+
+	t.Run("should move a plaintext token to the configured store", func(t *testing.T) {
+		store, config, fs := newStore(t, "plaintext-token")
+
+		token, err := store.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, "plaintext-token", *token)
+		require.Nil(t, config.API.Token)
+		fs.AssertNumberOfCalls(t, "WriteConfig", 1)
+
+		encrypted := &util.EncryptedFileCredentialStore{Path: path, Passphrase: passphrase}
+		token, err = encrypted.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, "plaintext-token", *token)
+	})
+
+	t.Run("should remove the plaintext token when storing a token", func(t *testing.T) {
+		store, config, fs := newStore(t, "old-token")
+
+		require.Nil(t, store.StoreToken("new-token"))
+		require.Nil(t, config.API.Token)
+		fs.AssertNumberOfCalls(t, "WriteConfig", 1)
+
+		token, err := store.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, "new-token", *token)
+		fs.AssertNumberOfCalls(t, "WriteConfig", 1)
+	})
+
+	t.Run("should keep the stored token over a plaintext token", func(t *testing.T) {
+		store, config, _ := newStore(t, "stale-token")
+
+		token, err := store.GetToken()
+		require.Nil(t, err)
+		require.Equal(t, "new-token", *token)
+		require.Nil(t, config.API.Token)
+	})
+}
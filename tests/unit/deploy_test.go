@@ -1,11 +1,13 @@
 package unit
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 
 	"github.com/Optum/dce-cli/configs"
+	"github.com/Optum/dce-cli/internal/constants"
 	svc "github.com/Optum/dce-cli/pkg/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -28,7 +30,7 @@ func TestDeployService_ErrorCreatingDirs(t *testing.T) {
 	mockFileSystemer.On("CreateConfigDirTree", mock.Anything).
 		Return(fmt.Errorf("Could not create folders: %s", "bad"))
 
-	err := service.Deploy(&svc.DeployConfig{})
+	err := service.Deploy(context.Background(), &svc.DeployConfig{})
 
 	mockFileSystemer.AssertExpectations(t)
 
@@ -61,7 +63,7 @@ func TestDeployService_DeclineToCreate(t *testing.T) {
 		On("Write", mock.Anything).
 		Return(nil)
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Namespace: "somethingpredictable",
 	})
 
@@ -99,7 +101,7 @@ func TestDeployService_InitThrowsError(t *testing.T) {
 		On("Write", mock.Anything).
 		Return(nil)
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Namespace: "somethingpredictable",
 	})
 
@@ -141,7 +143,7 @@ func TestDeployService_ApplyThrowsError(t *testing.T) {
 		On("Write", mock.Anything).
 		Return(nil)
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Namespace: "somethingpredictable",
 	})
 
@@ -198,7 +200,7 @@ func TestDeployService_FileExists(t *testing.T) {
 		On("Write", mock.Anything).
 		Return(nil)
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Namespace: "somethingpredictable",
 		Location:  "github.com/Optum/dce",
 	})
@@ -263,7 +265,7 @@ func TestDeployService_FileExistsWithOpts(t *testing.T) {
 	mockAwser.On("UploadDirectoryToS3", doesntMatter, s3bucket, "").Return(lambdas, codebuilds)
 	mockAwser.On("UpdateLambdasFromS3Assets", lambdas, s3bucket, "somethingpredictable")
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Namespace:      "somethingpredictable",
 		Location:       "github.com/Optum/dce",
 		TFInitOptions:  "-backend-config=\"address=demo.consul.io\" -backend-config=\"path=example_app/terraform_state\"",
@@ -319,7 +321,7 @@ func TestDeployService_DoesNotFileExist(t *testing.T) {
 	mockAwser.On("UploadDirectoryToS3", doesntMatter, s3bucket, "").Return(lambdas, codebuilds)
 	mockAwser.On("UpdateLambdasFromS3Assets", lambdas, s3bucket, "somethingpredictable")
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Namespace: "somethingpredictable",
 		Location:  "github.com/Optum/dce",
 	})
@@ -378,7 +380,7 @@ func TestDeployService_DoesNotFileExistAndUsingLocalRepo(t *testing.T) {
 	mockTFTemplater.
 		On("SetModuleSource", "/local/modules")
 
-	err := service.Deploy(&svc.DeployConfig{
+	err := service.Deploy(context.Background(), &svc.DeployConfig{
 		Location:  "/local",
 		Namespace: "somethingpredictable",
 	})
@@ -401,7 +403,7 @@ func TestDeployService_PostDeployDefault(t *testing.T) {
 
 	mockFileSystemer.On("WriteConfig").Return(nil)
 
-	err := service.PostDeploy(&svc.DeployConfig{
+	err := service.PostDeploy(context.Background(), &svc.DeployConfig{
 		TFInitOptions:  "",
 		TFApplyOptions: "-compact-warnings",
 	})
@@ -427,7 +429,7 @@ func TestDeployService_PostDeploySaveOpts(t *testing.T) {
 
 	mockFileSystemer.On("WriteConfig").Return(nil)
 
-	err := service.PostDeploy(&svc.DeployConfig{
+	err := service.PostDeploy(context.Background(), &svc.DeployConfig{
 		SaveTFOptions:  true,
 		TFInitOptions:  "",
 		TFApplyOptions: "-compact-warnings",
@@ -444,6 +446,27 @@ func TestDeployService_PostDeploySaveOpts(t *testing.T) {
 	mockTerraformer.AssertExpectations(t)
 }
 
+func TestDeployService_PostDeployContext(t *testing.T) {
+	type testKey struct{}
+	ctx := context.WithValue(context.Background(), testKey{}, "command")
+
+	emptyConfig := configs.Root{}
+	initMocks(emptyConfig)
+
+	// Terraform should be run with the command's context
+	mockTerraformer.On("GetOutput", mock.MatchedBy(func(tfCtx context.Context) bool {
+		return tfCtx.Value(testKey{}) == "command" &&
+			tfCtx.Value(constants.DeployLogFileKey) == "deploy.log"
+	}), "api_url").Return("https://some-api-id.execute-api.us-east-1.amazonaws.com/api", nil)
+
+	mockFileSystemer.On("WriteConfig").Return(nil)
+
+	err := service.PostDeploy(ctx, &svc.DeployConfig{DeployLogFile: "deploy.log"})
+	assert.Nil(t, err)
+
+	mockTerraformer.AssertExpectations(t)
+}
+
 func Test_mockFileInfo_Name(t *testing.T) {
 	tests := []struct {
 		name string
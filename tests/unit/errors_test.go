@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apiclient "github.com/Optum/dce-cli/client"
+	"github.com/Optum/dce-cli/client/operations"
+	"github.com/Optum/dce-cli/configs"
+	util "github.com/Optum/dce-cli/internal/util"
+	svc "github.com/Optum/dce-cli/pkg/service"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceErrors(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		apiErr       error
+		expectedKind svc.ErrorKind
+	}{
+		{
+			name:         "should return a not found error for a 404 response",
+			apiErr:       &runtime.APIError{OperationName: "getLeasesIdNotFound", Code: 404},
+			expectedKind: svc.NotFoundError,
+		},
+		{
+			name:         "should return an unauthorized error for a 403 response",
+			apiErr:       operations.NewGetLeasesIDForbidden(),
+			expectedKind: svc.UnauthorizedError,
+		},
+		{
+			name:         "should return a conflict error for a 409 response",
+			apiErr:       &runtime.APIError{Code: 409},
+			expectedKind: svc.ConflictError,
+		},
+		{
+			name:         "should return a validation error for a 400 response",
+			apiErr:       &runtime.APIError{Code: 400},
+			expectedKind: svc.ValidationError,
+		},
+		{
+			name:         "should return a server error for a 500 response",
+			apiErr:       &runtime.APIError{Code: 500},
+			expectedKind: svc.ServerError,
+		},
+		{
+			name:         "should return a timeout error when the request times out",
+			apiErr:       fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			expectedKind: svc.TimeoutError,
+		},
+		{
+			name:         "should return an unknown error for other failures",
+			apiErr:       errors.New("something went wrong"),
+			expectedKind: svc.UnknownError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			initMocks(configs.Root{})
+			mockAPIer.On("GetLeasesID", mock.Anything, nil).Return(nil, tc.apiErr)
+
+			err := service.GetLease(context.Background(), "lease-id")
+			require.NotNil(t, err)
+			require.Equal(t, tc.expectedKind, svc.KindOf(err))
+			require.Contains(t, err.Error(), "failed to get lease")
+			require.True(t, errors.Is(err, tc.apiErr))
+		})
+	}
+
+	t.Run("should return a validation error when ending a lease without an ID", func(t *testing.T) {
+		initMocks(configs.Root{})
+
+		err := service.EndLease(context.Background(), "", "", "")
+		require.NotNil(t, err)
+		require.Equal(t, svc.ValidationError, svc.KindOf(err))
+	})
+}
+
+func TestAPIErrorDecoding(t *testing.T) {
+
+	// getLeaseError returns the error from the API client,
+	// for a server which responds with the given status and body
+	getLeaseError := func(t *testing.T, status int, body string) error {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		defer server.Close()
+
+		transport := httptransport.New(strings.TrimPrefix(server.URL, "http://"), "/", []string{"http"})
+		client := apiclient.New(&util.ErrorResponseTransport{Transport: transport}, strfmt.Default)
+		params := operations.NewGetLeasesIDParams()
+		params.ID = "123"
+		_, err := client.Operations.GetLeasesID(params, nil)
+		require.NotNil(t, err)
+		return err
+	}
+
+	t.Run("should return error responses from the transport", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": "NotFoundError", "message": "lease \"123\" not found"}}`))
+		}))
+		defer server.Close()
+
+		initMocks(configs.Root{})
+		client := &http.Client{Transport: &util.Sig4RoundTripper{
+			Proxied: http.DefaultTransport,
+			Creds:   credentials.NewStaticCredentials("id", "secret", ""),
+			Region:  "us-east-1",
+			Logger:  &spyLogger,
+		}}
+		res, err := client.Get(server.URL + "/leases/123")
+		require.Nil(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err)
+		require.Contains(t, string(body), "NotFoundError")
+	})
+
+	t.Run("should decode the DCE error response body", func(t *testing.T) {
+		apiErr := getLeaseError(t, http.StatusNotFound, `{"error": {"code": "NotFoundError", "message": "lease \"123\" not found"}}`)
+		var rtErr *runtime.APIError
+		require.True(t, errors.As(apiErr, &rtErr))
+		require.Equal(t, 404, rtErr.Code)
+
+		initMocks(configs.Root{})
+		mockAPIer.On("GetLeasesID", mock.Anything, nil).Return(nil, apiErr)
+
+		err := service.GetLease(context.Background(), "123")
+		var svcErr *svc.Error
+		require.True(t, errors.As(err, &svcErr))
+		require.Equal(t, svc.NotFoundError, svcErr.Kind)
+		require.Equal(t, 404, svcErr.StatusCode)
+		require.Equal(t, "NotFoundError", svcErr.Code)
+		require.Equal(t, `lease "123" not found`, svcErr.Message)
+	})
+
+	t.Run("should decode API Gateway error messages", func(t *testing.T) {
+		apiErr := getLeaseError(t, http.StatusForbidden, `{"message": "User is not authorized to access this resource"}`)
+
+		initMocks(configs.Root{})
+		mockAPIer.On("GetLeasesID", mock.Anything, nil).Return(nil, apiErr)
+
+		err := service.GetLease(context.Background(), "123")
+		require.Equal(t, svc.UnauthorizedError, svc.KindOf(err))
+		require.Contains(t, err.Error(), "User is not authorized to access this resource (HTTP 403)")
+	})
+
+	t.Run("should explain API errors in plain words, with a remedy", func(t *testing.T) {
+		apiErr := getLeaseError(t, http.StatusNotFound, `{"error": {"code": "NotFoundError", "message": "lease \"123\" not found"}}`)
+
+		initMocks(configs.Root{})
+		mockAPIer.On("GetLeasesID", mock.Anything, nil).Return(nil, apiErr)
+
+		err := service.GetLease(context.Background(), "123")
+		require.Equal(t,
+			"failed to get lease: lease \"123\" not found (HTTP 404 NotFoundError)\n"+
+				"Check that the lease ID is correct. Run `dce leases list` to see leases.",
+			err.Error(),
+		)
+	})
+
+	t.Run("should suggest running `dce auth` when forbidden", func(t *testing.T) {
+		initMocks(configs.Root{})
+		mockAPIer.On("GetLeasesID", mock.Anything, nil).Return(nil, operations.NewGetLeasesIDForbidden())
+
+		err := service.GetLease(context.Background(), "123")
+		require.Equal(t,
+			"failed to get lease: Forbidden (HTTP 403)\n"+
+				"Run `dce auth` to log in again, and check that you have permission to get lease.",
+			err.Error(),
+		)
+	})
+}
@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Optum/dce-cli/configs"
+	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestWriteConfig(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	// newConfigFile writes a config file, and returns its path
+	newConfigFile := func(t *testing.T, yamlStr string) string {
+		dir, err := ioutil.TempDir("", "dce-config")
+		require.Nil(t, err)
+		confFile := filepath.Join(dir, "config.yaml")
+		require.Nil(t, ioutil.WriteFile(confFile, []byte(yamlStr), 0644))
+		return confFile
+	}
+
+	readConfigFile := func(t *testing.T, confFile string) *configs.Root {
+		data, err := ioutil.ReadFile(confFile)
+		require.Nil(t, err)
+		var config configs.Root
+		require.Nil(t, yaml.Unmarshal(data, &config))
+		return &config
+	}
+
+	t.Run("should keep changes made by other processes", func(t *testing.T) {
+		confFile := newConfigFile(t, "api:\n  host: dce.example.com\n  basepath: /api\n")
+		defer os.RemoveAll(filepath.Dir(confFile))
+
+		// Two dce processes read the same config file
+		fs1 := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: confFile}
+		require.Nil(t, fs1.ReadInConfig())
+		fs2 := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: confFile}
+		require.Nil(t, fs2.ReadInConfig())
+
+		// One saves the API token
+		fs1.Config.API.Token = str("my-api-token")
+		require.Nil(t, fs1.WriteConfig())
+
+		// The other updates the API host, after deploying DCE
+		fs2.Config.API.Host = str("new.example.com")
+		fs2.Config.Deploy.Version = str("0.29.0")
+		require.Nil(t, fs2.WriteConfig())
+
+		saved := readConfigFile(t, confFile)
+		require.Equal(t, "my-api-token", *saved.API.Token)
+		require.Equal(t, "new.example.com", *saved.API.Host)
+		require.Equal(t, "/api", *saved.API.BasePath)
+		require.Equal(t, "0.29.0", *saved.Deploy.Version)
+
+		// Removing a setting should only remove that setting
+		fs1.Config.API.Token = nil
+		require.Nil(t, fs1.WriteConfig())
+		saved = readConfigFile(t, confFile)
+		require.Nil(t, saved.API.Token)
+		require.Equal(t, "new.example.com", *saved.API.Host)
+	})
+
+	t.Run("should write the current config file version", func(t *testing.T) {
+		confFile := newConfigFile(t, "api:\n  host: dce.example.com\n")
+		defer os.RemoveAll(filepath.Dir(confFile))
+
+		fs := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: confFile}
+		require.Nil(t, fs.ReadInConfig())
+		fs.Config.Region = str("us-east-1")
+		require.Nil(t, fs.WriteConfig())
+
+		saved := readConfigFile(t, confFile)
+		require.Equal(t, configs.CurrentVersion, saved.Version)
+		require.Equal(t, "dce.example.com", *saved.API.Host)
+	})
+
+	t.Run("should only allow the user to read the config file", func(t *testing.T) {
+		confFile := newConfigFile(t, "api:\n  host: dce.example.com\n")
+		defer os.RemoveAll(filepath.Dir(confFile))
+
+		fs := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: confFile}
+		require.Nil(t, fs.ReadInConfig())
+		fs.Config.API.Token = str("my-api-token")
+		require.Nil(t, fs.WriteConfig())
+
+		info, err := os.Stat(confFile)
+		require.Nil(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("should not lose changes from concurrent writes", func(t *testing.T) {
+		confFile := newConfigFile(t, "api:\n  host: dce.example.com\n")
+		defer os.RemoveAll(filepath.Dir(confFile))
+
+		// Every process reads the config file before any of them write it
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < 10; i++ {
+			fs := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: confFile}
+			require.Nil(t, fs.ReadInConfig())
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				fs.Config.Contexts = map[string]*configs.Context{
+					fmt.Sprintf("ctx-%d", i): {Region: str("us-east-1")},
+				}
+				require.Nil(t, fs.WriteConfig())
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		saved := readConfigFile(t, confFile)
+		require.Len(t, saved.Contexts, 10)
+		require.Equal(t, "dce.example.com", *saved.API.Host)
+	})
+}
+
+func TestMergeChanges(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	from := &configs.Root{
+		Region:   str("us-east-1"),
+		Contexts: map[string]*configs.Context{"prod": {Region: str("us-east-1")}, "old": {}},
+	}
+	to := &configs.Root{
+		Region: str("us-east-1"),
+		Contexts: map[string]*configs.Context{
+			"prod": {Region: str("us-west-2")},
+			"dev":  {Region: str("us-east-2")},
+		},
+	}
+	onDisk := &configs.Root{
+		Region: str("us-east-2"),
+		Contexts: map[string]*configs.Context{
+			"prod": {Region: str("us-east-1"), API: configs.API{Token: str("prod-token")}},
+			"old":  {},
+		},
+	}
+
+	onDisk.MergeChanges(from, to)
+	require.Equal(t, "us-east-2", *onDisk.Region)
+	require.Equal(t, "us-west-2", *onDisk.Contexts["prod"].Region)
+	require.Equal(t, "prod-token", *onDisk.Contexts["prod"].API.Token)
+	require.Equal(t, "us-east-2", *onDisk.Contexts["dev"].Region)
+	require.NotContains(t, onDisk.Contexts, "old")
+}
@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"testing"
+
+	observ "github.com/Optum/dce-cli/internal/observation"
+	"github.com/Optum/dce-cli/models"
+	"github.com/stretchr/testify/require"
+)
+
+var formatTestLeases = []*models.Lease{
+	{
+		ID:             "lease-1",
+		AccountID:      "123456789012",
+		PrincipalID:    "jdoe",
+		LeaseStatus:    "Active",
+		BudgetAmount:   100,
+		BudgetCurrency: "USD",
+		ExpiresOn:      1577836800,
+	},
+	{
+		ID:             "lease-2",
+		AccountID:      "210987654321",
+		PrincipalID:    "asmith",
+		LeaseStatus:    "Inactive",
+		BudgetAmount:   25.5,
+		BudgetCurrency: "USD",
+		ExpiresOn:      1580515200,
+	},
+}
+
+func TestFormatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		columns  []string
+		data     interface{}
+		expected string
+	}{
+		{
+			name:   "json",
+			format: "json",
+			data:   &models.Usage{AccountID: "123456789012", CostAmount: 1.5},
+			expected: `{
+	"accountId": "123456789012",
+	"costAmount": 1.5
+}`,
+		},
+		{
+			name:   "yaml",
+			format: "yaml",
+			data:   formatTestLeases[:1],
+			expected: `- accountId: "123456789012"
+  budgetAmount: 100
+  budgetCurrency: USD
+  budgetNotificationEmails: null
+  expiresOn: 1577836800
+  id: lease-1
+  leaseStatus: Active
+  principalId: jdoe
+`,
+		},
+		{
+			name:   "table with default columns",
+			format: "table",
+			data:   formatTestLeases,
+			expected: `ID        ACCOUNT ID     PRINCIPAL ID   LEASE STATUS   BUDGET AMOUNT   BUDGET CURRENCY   EXPIRES ON
+lease-1   123456789012   jdoe           Active         100             USD               2020-01-01T00:00:00Z
+lease-2   210987654321   asmith         Inactive       25.5            USD               2020-02-01T00:00:00Z
+`,
+		},
+		{
+			name:    "table with selected columns",
+			format:  "table",
+			columns: []string{"id", "LEASE STATUS"},
+			data:    formatTestLeases,
+			expected: `ID        LEASE STATUS
+lease-1   Active
+lease-2   Inactive
+`,
+		},
+		{
+			name:    "csv",
+			format:  "csv",
+			columns: []string{"id", "accountId", "expiresOn"},
+			data:    &models.Lease{ID: "lease-1", AccountID: "123456789012", ExpiresOn: 1577836800},
+			expected: `id,accountId,expiresOn
+lease-1,123456789012,1577836800
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := observ.NewFormatter(tt.format, tt.columns)
+			require.Nil(t, err)
+
+			output, err := formatter.Format(tt.data)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+func TestFormatterErrors(t *testing.T) {
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := observ.NewFormatter("xml", nil)
+		require.EqualError(t, err, "invalid output format \"xml\": must be one of json, yaml, table, csv")
+	})
+
+	t.Run("invalid column", func(t *testing.T) {
+		formatter, err := observ.NewFormatter("table", []string{"notAColumn"})
+		require.Nil(t, err)
+
+		_, err = formatter.Format(formatTestLeases)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "invalid column \"notAColumn\"")
+	})
+}
+
+func TestQueryFormatter(t *testing.T) {
+	jsonFormatter, err := observ.NewFormatter("json", nil)
+	require.Nil(t, err)
+
+	formatter, err := observ.NewQueryFormatter("$[*].accountId", jsonFormatter)
+	require.Nil(t, err)
+
+	output, err := formatter.Format(formatTestLeases)
+	require.Nil(t, err)
+	require.Equal(t, `[
+	"123456789012",
+	"210987654321"
+]`, string(output))
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	t.Run("renders Go struct fields", func(t *testing.T) {
+		formatter, err := observ.NewTemplateFormatter("{{.ID}} expires {{date .ExpiresOn}} ({{int .ExpiresOn}})")
+		require.Nil(t, err)
+
+		output, err := formatter.Format(formatTestLeases[0])
+		require.Nil(t, err)
+		require.Equal(t, "lease-1 expires 2020-01-01T00:00:00Z (1577836800)", string(output))
+	})
+
+	t.Run("ranges over lists", func(t *testing.T) {
+		formatter, err := observ.NewTemplateFormatter("{{range .}}{{.PrincipalID}}\n{{end}}")
+		require.Nil(t, err)
+
+		output, err := formatter.Format(formatTestLeases)
+		require.Nil(t, err)
+		require.Equal(t, "jdoe\nasmith\n", string(output))
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := observ.NewTemplateFormatter("{{.ID")
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "invalid template")
+	})
+}
@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	observ "github.com/Optum/dce-cli/internal/observation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPath(t *testing.T) {
+	var data interface{}
+	err := json.Unmarshal([]byte(`{
+		"leases": [
+			{"id": "a", "accountId": "111", "budget": {"amount": 10}},
+			{"id": "b", "accountId": "222", "budget": {"amount": 20}},
+			{"id": "c", "accountId": "333", "budget": {"amount": 30}}
+		],
+		"weird.key": "dotted"
+	}`), &data)
+	require.Nil(t, err)
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"$", data},
+		{"$.leases[0].id", "a"},
+		{"$.leases[-1].id", "c"},
+		{"$['weird.key']", "dotted"},
+		{"$.leases[*].accountId", []interface{}{"111", "222", "333"}},
+		{"$.leases[1:].id", []interface{}{"b", "c"}},
+		{"$.leases[0,2].id", []interface{}{"a", "c"}},
+		{"$.leases[0]['id','accountId']", []interface{}{"a", "111"}},
+		{"$..amount", []interface{}{float64(10), float64(20), float64(30)}},
+		{".leases[1].budget.amount", float64(20)},
+		{"$.missing", nil},
+		{"$.missing[*]", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := observ.ParseJSONPath(tt.path)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, path.Evaluate(data))
+		})
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	for _, expr := range []string{"$.leases[", "$.leases[?(@.id)]", "$.", "$leases", "$[a]"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := observ.ParseJSONPath(expr)
+			require.NotNil(t, err)
+		})
+	}
+}
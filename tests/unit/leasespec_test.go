@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"testing"
+
+	svc "github.com/Optum/dce-cli/pkg/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLeaseSpecs(t *testing.T) {
+	env := map[string]string{
+		"DCE_PRINCIPAL": "user-1",
+		"TEAM_EMAIL":    "team@example.com",
+		"BUDGET":        "75.5",
+		"YAML_CHARS":    "a: b # c\n- d",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		expected []*svc.LeaseSpec
+		expErr   string
+	}{
+		{
+			name: "single YAML document",
+			data: `principalId: user-1
+budgetAmount: 100
+budgetCurrency: USD
+budgetNotificationEmails:
+  - user-1@example.com
+expiresOn: 7d
+`,
+			expected: []*svc.LeaseSpec{{
+				PrincipalID:              "user-1",
+				BudgetAmount:             100,
+				BudgetCurrency:           "USD",
+				BudgetNotificationEmails: []string{"user-1@example.com"},
+				ExpiresOn:                "7d",
+			}},
+		},
+		{
+			name: "multiple YAML documents, and lists",
+			data: `principalId: user-1
+budgetAmount: 100
+---
+- principalId: user-2
+  expiresOn: 1700000000
+- principalId: user-3
+---
+`,
+			expected: []*svc.LeaseSpec{
+				{PrincipalID: "user-1", BudgetAmount: 100},
+				{PrincipalID: "user-2", ExpiresOn: "1700000000"},
+				{PrincipalID: "user-3"},
+			},
+		},
+		{
+			name: "JSON",
+			data: "[\n\t{\"principalId\": \"user-1\", \"budgetAmount\": 50.5},\n\t{\"principalId\": \"user-2\"}\n]",
+			expected: []*svc.LeaseSpec{
+				{PrincipalID: "user-1", BudgetAmount: 50.5},
+				{PrincipalID: "user-2"},
+			},
+		},
+		{
+			name: "env vars",
+			data: `principalId: $DCE_PRINCIPAL
+budgetNotificationEmails: ["${TEAM_EMAIL}"]
+budgetCurrency: ${CURRENCY:-EUR}
+expiresOn: "$$HOME"
+`,
+			expected: []*svc.LeaseSpec{{
+				PrincipalID:              "user-1",
+				BudgetNotificationEmails: []string{"team@example.com"},
+				BudgetCurrency:           "EUR",
+				ExpiresOn:                "$HOME",
+			}},
+		},
+		{
+			name: "env vars containing YAML syntax",
+			data: `principalId: $YAML_CHARS
+budgetAmount: ${BUDGET}
+# $NOT_SET in a comment is ignored
+`,
+			expected: []*svc.LeaseSpec{{
+				PrincipalID:  "a: b # c\n- d",
+				BudgetAmount: 75.5,
+			}},
+		},
+		{
+			name:   "missing env vars",
+			data:   "principalId: ${PRINCIPAL}\nbudgetCurrency: $CURRENCY\n",
+			expErr: "env vars are not set: CURRENCY, PRINCIPAL. Use ${VAR:-default} to set a default",
+		},
+		{
+			name:   "unknown fields",
+			data:   "principalId: user-1\n---\nprincipalId: user-2\nbudget: 100\n",
+			expErr: "document 2: yaml: unmarshal errors:\n  line 4: field budget not found",
+		},
+		{
+			name:   "invalid types",
+			data:   "principalId: user-1\nbudgetNotificationEmails: user-1@example.com\n",
+			expErr: "document 1: yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `user-1@...` into []string",
+		},
+		{
+			name:   "invalid budget amount",
+			data:   "principalId: user-1\n---\nbudgetAmount: lots\n",
+			expErr: "document 2: invalid budgetAmount \"lots\": must be a number",
+		},
+		{
+			name:     "empty file",
+			data:     "",
+			expected: []*svc.LeaseSpec{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := svc.ParseLeaseSpecs([]byte(tt.data), lookupEnv)
+			if tt.expErr != "" {
+				require.NotNil(t, err)
+				require.Equal(t, tt.expErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, specs)
+		})
+	}
+}
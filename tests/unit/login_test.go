@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -10,8 +11,10 @@ import (
 	"github.com/Optum/dce-cli/client/operations"
 	"github.com/Optum/dce-cli/configs"
 	"github.com/Optum/dce-cli/internal/constants"
+	"github.com/Optum/dce-cli/internal/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 var expectedAccessKeyID = "expectedAccessKeyID"
@@ -64,10 +67,10 @@ func TestLeaseLoginGivenFlags(t *testing.T) {
 			// Arrange
 			emptyConfig := configs.Root{}
 			initMocks(emptyConfig)
-			reqParams := &operations.PostLeasesIDAuthParams{
-				ID: tc.leaseID,
-			}
-			reqParams.SetTimeout(20 * time.Second)
+			ctx := service2.WithAPITimeout(context.Background(), 20*time.Second)
+			reqParams := mock.MatchedBy(func(params *operations.PostLeasesIDAuthParams) bool {
+				return params.ID == tc.leaseID && hasDeadlineWithin(params.Context, 20*time.Second)
+			})
 			mockAPIer.On("PostLeasesIDAuth", reqParams, nil).Return(&operations.PostLeasesIDAuthCreated{
 				Payload: &operations.PostLeasesIDAuthCreatedBody{
 					AccessKeyID:     expectedAccessKeyID,
@@ -77,7 +80,14 @@ func TestLeaseLoginGivenFlags(t *testing.T) {
 				},
 			}, nil)
 			if !(tc.opts.OpenBrowser || tc.opts.PrintCreds) {
-				mockAwser.On("ConfigureAWSCLICredentials", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				mockAwser.On("ConfigureAWSCLICredentials", &util.AWSCLICredentials{
+					Profile:         tc.opts.CliProfile,
+					AccessKeyID:     expectedAccessKeyID,
+					SecretAccessKey: expectedSecretAccessKey,
+					SessionToken:    expectedSessionToken,
+					LeaseID:         tc.leaseID,
+				}).Return(nil)
+				mockFileSystemer.On("WriteConfig").Return(nil)
 			}
 			if tc.isWeberCalled {
 				mockWeber.On("OpenURL", expectedConsoleURL)
@@ -88,13 +98,17 @@ func TestLeaseLoginGivenFlags(t *testing.T) {
 			}
 
 			// Act
-			service.LoginByID(tc.leaseID, tc.opts)
+			err := service.LoginByID(ctx, tc.leaseID, tc.opts)
+			require.Nil(t, err)
 
 			// Assert
 			mockWeber.AssertExpectations(t)
 			mockAPIer.AssertExpectations(t)
 			mockOutputWriter.AssertExpectations(t)
 			if !(tc.opts.OpenBrowser || tc.opts.PrintCreds) {
+				// The AWS CLI profile should be saved, so `dce auth logout` can remove it
+				mockFileSystemer.AssertExpectations(t)
+				require.Equal(t, []string{tc.opts.CliProfile}, service.Config.AWSProfiles)
 				assert.Contains(t, spyLogger.Msg, tc.expectedOut)
 			}
 		})
@@ -105,8 +119,9 @@ func TestLeaseLoginNoID(t *testing.T) {
 	initMocks(configs.Root{})
 
 	// Mock the `POST /leases/auth` endpoint
-	reqParams := &operations.PostLeasesAuthParams{}
-	reqParams.SetTimeout(20 * time.Second)
+	reqParams := mock.MatchedBy(func(params *operations.PostLeasesAuthParams) bool {
+		return hasDeadlineWithin(params.Context, service2.DefaultAPITimeout)
+	})
 	mockAPIer.On("PostLeasesAuth", reqParams, nil).
 		Return(&operations.PostLeasesAuthCreated{
 			Payload: &operations.PostLeasesAuthCreatedBody{
@@ -122,10 +137,21 @@ func TestLeaseLoginNoID(t *testing.T) {
 	mockWeber.On("OpenURL", "console-url")
 
 	// Run the login command
-	service.Login(&service2.LeaseLoginOptions{
+	err := service.Login(context.Background(), &service2.LeaseLoginOptions{
 		OpenBrowser: true,
 	})
+	require.Nil(t, err)
 
 	// Check that we called Weber.OpenURL()
 	mockWeber.AssertExpectations(t)
 }
+
+// hasDeadlineWithin checks that an API request context times out
+// within the expected API timeout
+func hasDeadlineWithin(ctx context.Context, timeout time.Duration) bool {
+	if ctx == nil {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) <= timeout && time.Until(deadline) > timeout-time.Second
+}
@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Optum/dce-cli/configs"
+	utl "github.com/Optum/dce-cli/internal/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	legacyYAML := strings.Join([]string{
+		"system:",
+		"  masterAccount:",
+		"    credentials:",
+		"      awsAccessKeyID: old-key",
+		"api:",
+		"  host: dce.example.com",
+		"  basePath: /api",
+		"region: us-east-1",
+		"githubToken: old-token",
+		"",
+	}, "\n")
+
+	t.Run("should upgrade legacy config to the current version", func(t *testing.T) {
+		migrated, applied, err := configs.Migrate([]byte(legacyYAML))
+		require.Nil(t, err)
+		require.Len(t, applied, 1)
+		require.Equal(t, strings.Join([]string{
+			"version: 1",
+			"api:",
+			"  host: dce.example.com",
+			"  basepath: /api",
+			"region: us-east-1",
+			"",
+		}, "\n"), string(migrated))
+	})
+
+	t.Run("should not change up-to-date config", func(t *testing.T) {
+		current := "version: 1\napi:\n  host: dce.example.com\n"
+		migrated, applied, err := configs.Migrate([]byte(current))
+		require.Nil(t, err)
+		require.Empty(t, applied)
+		require.Equal(t, current, string(migrated))
+	})
+
+	t.Run("should not change unversioned config, which is already up to date", func(t *testing.T) {
+		unversioned := "api:\n  host: dce.example.com\n  basepath: /api\n"
+		migrated, applied, err := configs.Migrate([]byte(unversioned))
+		require.Nil(t, err)
+		require.Empty(t, applied)
+		require.Equal(t, unversioned, string(migrated))
+	})
+
+	t.Run("should reject config from newer versions of dce", func(t *testing.T) {
+		_, _, err := configs.Migrate([]byte("version: 99\n"))
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "Please upgrade dce")
+	})
+
+	t.Run("should move legacy config files, and back them up", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "dce-migrate")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		legacyFile := filepath.Join(dir, ".dce.yaml")
+		require.Nil(t, ioutil.WriteFile(legacyFile, []byte(legacyYAML), 0644))
+		configFile := filepath.Join(dir, ".dce", "config.yaml")
+
+		fs := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: configFile}
+		applied, err := fs.MigrateConfig([]string{legacyFile})
+		require.Nil(t, err)
+		require.Len(t, applied, 3)
+
+		require.Nil(t, fs.ReadInConfig())
+		require.Equal(t, configs.CurrentVersion, fs.Config.Version)
+		require.Equal(t, "/api", *fs.Config.API.BasePath)
+		info, err := os.Stat(configFile)
+		require.Nil(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		_, err = os.Stat(legacyFile)
+		require.True(t, os.IsNotExist(err))
+		backups, err := filepath.Glob(legacyFile + ".*.bak")
+		require.Nil(t, err)
+		require.Len(t, backups, 1)
+		backup, err := ioutil.ReadFile(backups[0])
+		require.Nil(t, err)
+		require.Equal(t, legacyYAML, string(backup))
+
+		// Nothing more to migrate
+		applied, err = fs.MigrateConfig([]string{legacyFile})
+		require.Nil(t, err)
+		require.Empty(t, applied)
+	})
+	t.Run("should migrate once, when run concurrently", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "dce-migrate")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		legacyFile := filepath.Join(dir, ".dce.yaml")
+		require.Nil(t, ioutil.WriteFile(legacyFile, []byte(legacyYAML), 0644))
+		configFile := filepath.Join(dir, ".dce", "config.yaml")
+
+		var wg sync.WaitGroup
+		var migrations int32
+		start := make(chan struct{})
+		for i := 0; i < 20; i++ {
+			fs := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: configFile}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				applied, err := fs.MigrateConfig([]string{legacyFile})
+				require.Nil(t, err)
+				if len(applied) > 0 {
+					atomic.AddInt32(&migrations, 1)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		require.Equal(t, int32(1), migrations)
+		backups, err := filepath.Glob(legacyFile + ".*.bak")
+		require.Nil(t, err)
+		require.Len(t, backups, 1)
+		fs := &utl.FileSystemUtil{Config: &configs.Root{}, ConfigFile: configFile}
+		require.Nil(t, fs.ReadInConfig())
+		require.Equal(t, "/api", *fs.Config.API.BasePath)
+	})
+}
@@ -0,0 +1,230 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/configs"
+	util "github.com/Optum/dce-cli/internal/util"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+// switchableProvider returns whichever access key ID is currently set
+type switchableProvider struct {
+	accessKeyID string
+}
+
+func (p *switchableProvider) Retrieve() (credentials.Value, error) {
+	return credentials.Value{AccessKeyID: p.accessKeyID, SecretAccessKey: "secret"}, nil
+}
+
+func (p *switchableProvider) IsExpired() bool {
+	return false
+}
+
+func TestSig4RoundTripperReauthenticate(t *testing.T) {
+
+	// newServer returns a server which rejects requests signed
+	// with the "expired" access key ID
+	newServer := func(expiredHeader string, expiredBody string) (*httptest.Server, *[]string) {
+		var accessKeyIDs []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			accessKeyID := strings.Split(strings.SplitN(auth, "Credential=", 2)[1], "/")[0]
+			accessKeyIDs = append(accessKeyIDs, accessKeyID)
+			if accessKeyID == "expired" {
+				if expiredHeader != "" {
+					w.Header().Set("x-amzn-ErrorType", expiredHeader)
+				}
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(expiredBody))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		return server, &accessKeyIDs
+	}
+
+	// newClient returns a client, which calls reauthenticate
+	// when the server rejects its credentials
+	newClient := func(provider *switchableProvider, reauthenticate func() error) *http.Client {
+		initMocks(configs.Root{})
+		return &http.Client{Transport: &util.Sig4RoundTripper{
+			Proxied:        http.DefaultTransport,
+			Creds:          credentials.NewCredentials(provider),
+			Region:         "us-east-1",
+			Logger:         &spyLogger,
+			Retry:          util.RetryPolicy{MaxAttempts: 1},
+			Reauthenticate: reauthenticate,
+		}}
+	}
+
+	t.Run("should re-authenticate and retry with new credentials", func(t *testing.T) {
+		server, accessKeyIDs := newServer("ExpiredTokenException", `{"message":"The security token included in the request is expired"}`)
+		defer server.Close()
+
+		provider := &switchableProvider{accessKeyID: "expired"}
+		reauthCount := 0
+		client := newClient(provider, func() error {
+			reauthCount++
+			provider.accessKeyID = "renewed"
+			return nil
+		})
+
+		res, err := client.Post(server.URL+"/leases", "application/json", strings.NewReader(`{}`))
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Equal(t, 1, reauthCount)
+		require.Equal(t, []string{"expired", "renewed"}, *accessKeyIDs)
+	})
+
+	t.Run("should detect expired credentials from the response message", func(t *testing.T) {
+		server, accessKeyIDs := newServer("", `{"message":"Signature expired: 20200101T000000Z is now earlier than 20200101T000500Z"}`)
+		defer server.Close()
+
+		provider := &switchableProvider{accessKeyID: "expired"}
+		client := newClient(provider, func() error {
+			provider.accessKeyID = "renewed"
+			return nil
+		})
+
+		res, err := client.Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Equal(t, []string{"expired", "renewed"}, *accessKeyIDs)
+	})
+
+	t.Run("should only retry once", func(t *testing.T) {
+		server, accessKeyIDs := newServer("ExpiredTokenException", `{"message":"The security token included in the request is expired"}`)
+		defer server.Close()
+
+		provider := &switchableProvider{accessKeyID: "expired"}
+		reauthCount := 0
+		client := newClient(provider, func() error {
+			reauthCount++
+			return nil
+		})
+
+		res, err := client.Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 403, res.StatusCode)
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err)
+		require.Contains(t, string(body), "The security token included in the request is expired")
+		require.Equal(t, 1, reauthCount)
+		require.Len(t, *accessKeyIDs, 2)
+	})
+
+	t.Run("should return the API error, if re-authentication fails", func(t *testing.T) {
+		server, accessKeyIDs := newServer("ExpiredTokenException", `{"message":"The security token included in the request is expired"}`)
+		defer server.Close()
+
+		client := newClient(&switchableProvider{accessKeyID: "expired"}, func() error {
+			return errors.New("dce is not running interactively")
+		})
+
+		res, err := client.Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 403, res.StatusCode)
+		require.Len(t, *accessKeyIDs, 1)
+	})
+
+	t.Run("should not re-authenticate background requests", func(t *testing.T) {
+		server, accessKeyIDs := newServer("ExpiredTokenException", `{"message":"The security token included in the request is expired"}`)
+		defer server.Close()
+
+		reauthCount := 0
+		client := newClient(&switchableProvider{accessKeyID: "expired"}, func() error {
+			reauthCount++
+			return nil
+		})
+
+		req, err := http.NewRequestWithContext(util.WithoutReauthentication(context.Background()), "GET", server.URL+"/leases", nil)
+		require.Nil(t, err)
+		res, err := client.Do(req)
+		require.Nil(t, err)
+		require.Equal(t, 403, res.StatusCode)
+		require.Equal(t, 0, reauthCount)
+		require.Len(t, *accessKeyIDs, 1)
+	})
+
+	t.Run("should not re-authenticate for other 403 errors", func(t *testing.T) {
+		server, accessKeyIDs := newServer("AccessDeniedException", `{"message":"User is not authorized to access this resource"}`)
+		defer server.Close()
+
+		reauthCount := 0
+		client := newClient(&switchableProvider{accessKeyID: "expired"}, func() error {
+			reauthCount++
+			return nil
+		})
+
+		res, err := client.Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 403, res.StatusCode)
+		require.Equal(t, 0, reauthCount)
+		require.Len(t, *accessKeyIDs, 1)
+	})
+
+	t.Run("should give the retry a new timeout, after logging in", func(t *testing.T) {
+		server, accessKeyIDs := newServer("ExpiredTokenException", `{}`)
+		defer server.Close()
+
+		provider := &switchableProvider{accessKeyID: "expired"}
+		client := newClient(provider, func() error {
+			// Login takes longer than the request timeout
+			time.Sleep(300 * time.Millisecond)
+			provider.accessKeyID = "renewed"
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/leases", nil)
+		require.Nil(t, err)
+		res, err := client.Do(req)
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Equal(t, []string{"expired", "renewed"}, *accessKeyIDs)
+	})
+
+	t.Run("should return a readable response body, after logging in", func(t *testing.T) {
+		// The body is still being sent after RoundTrip returns
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.Header.Get("Authorization"), "Credential=expired/") {
+				w.Header().Set("x-amzn-ErrorType", "ExpiredTokenException")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"id":`))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			_, _ = w.Write([]byte(`"lease-1"}`))
+		}))
+		defer server.Close()
+
+		provider := &switchableProvider{accessKeyID: "expired"}
+		client := newClient(provider, func() error {
+			provider.accessKeyID = "renewed"
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/leases", nil)
+		require.Nil(t, err)
+		res, err := client.Do(req)
+		require.Nil(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.Nil(t, err)
+		require.Equal(t, `{"id":"lease-1"}`, string(body))
+	})
+}
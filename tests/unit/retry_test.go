@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Optum/dce-cli/configs"
+	util "github.com/Optum/dce-cli/internal/util"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSig4RoundTripperRetry(t *testing.T) {
+
+	// newServer returns a server which responds with each of the given status codes in turn,
+	// and records the Authorization header of each request
+	newServer := func(statuses []int, headers map[string]string) (*httptest.Server, *[]string) {
+		var authHeaders []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+			for key, val := range headers {
+				w.Header().Set(key, val)
+			}
+			w.WriteHeader(statuses[len(authHeaders)-1])
+		}))
+		return server, &authHeaders
+	}
+
+	newClient := func(maxAttempts int, maxDelay time.Duration) *http.Client {
+		initMocks(configs.Root{})
+		return &http.Client{Transport: &util.Sig4RoundTripper{
+			Proxied: http.DefaultTransport,
+			Creds:   credentials.NewStaticCredentials("id", "secret", ""),
+			Region:  "us-east-1",
+			Logger:  &spyLogger,
+			Retry: util.RetryPolicy{
+				MaxAttempts: maxAttempts,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    maxDelay,
+			},
+		}}
+	}
+
+	t.Run("should retry throttled and unavailable requests, re-signing each attempt", func(t *testing.T) {
+		server, authHeaders := newServer([]int{429, 503, 200}, nil)
+		defer server.Close()
+
+		res, err := newClient(4, 10*time.Millisecond).Post(server.URL+"/leases", "application/json", strings.NewReader(`{"principalId":"jdoe"}`))
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Len(t, *authHeaders, 3)
+		for _, header := range *authHeaders {
+			require.Contains(t, header, "AWS4-HMAC-SHA256")
+		}
+	})
+
+	t.Run("should retry gateway errors for GET requests", func(t *testing.T) {
+		server, authHeaders := newServer([]int{502, 504, 200}, nil)
+		defer server.Close()
+
+		res, err := newClient(4, 10*time.Millisecond).Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Len(t, *authHeaders, 3)
+	})
+
+	t.Run("should not retry gateway errors for POST requests", func(t *testing.T) {
+		server, authHeaders := newServer([]int{502, 200}, nil)
+		defer server.Close()
+
+		res, err := newClient(4, 10*time.Millisecond).Post(server.URL+"/leases", "application/json", strings.NewReader(`{}`))
+		require.Nil(t, err)
+		require.Equal(t, 502, res.StatusCode)
+		require.Len(t, *authHeaders, 1)
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		server, authHeaders := newServer([]int{404, 200}, nil)
+		defer server.Close()
+
+		res, err := newClient(4, 10*time.Millisecond).Get(server.URL + "/leases/123")
+		require.Nil(t, err)
+		require.Equal(t, 404, res.StatusCode)
+		require.Len(t, *authHeaders, 1)
+	})
+
+	t.Run("should give up after max attempts", func(t *testing.T) {
+		server, authHeaders := newServer([]int{503, 503, 503, 200}, nil)
+		defer server.Close()
+
+		res, err := newClient(3, 10*time.Millisecond).Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 503, res.StatusCode)
+		require.Len(t, *authHeaders, 3)
+	})
+
+	t.Run("should wait for Retry-After", func(t *testing.T) {
+		server, authHeaders := newServer([]int{429, 200}, map[string]string{"Retry-After": "1"})
+		defer server.Close()
+
+		start := time.Now()
+		res, err := newClient(2, 5*time.Second).Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Len(t, *authHeaders, 2)
+		require.True(t, time.Since(start) >= time.Second)
+	})
+
+	t.Run("should not wait longer than the max delay for Retry-After", func(t *testing.T) {
+		server, authHeaders := newServer([]int{429, 200}, map[string]string{"Retry-After": "3600"})
+		defer server.Close()
+
+		start := time.Now()
+		res, err := newClient(2, 10*time.Millisecond).Get(server.URL + "/leases")
+		require.Nil(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		require.Len(t, *authHeaders, 2)
+		require.True(t, time.Since(start) < time.Second)
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+
+	t.Run("Backoff should grow exponentially, up to the max delay", func(t *testing.T) {
+		policy := util.RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+		for retry := 1; retry <= 10; retry++ {
+			maxDelay := 100 * time.Millisecond << uint(retry-1)
+			if maxDelay > time.Second {
+				maxDelay = time.Second
+			}
+			delay := policy.Backoff(retry)
+			require.True(t, delay >= 0 && delay <= maxDelay, "retry %d: %s > %s", retry, delay, maxDelay)
+		}
+	})
+
+	t.Run("NewRetryPolicy should override defaults with config", func(t *testing.T) {
+		maxAttempts := 2
+		baseDelay := "1s"
+		policy, err := util.NewRetryPolicy(configs.Retry{MaxAttempts: &maxAttempts, BaseDelay: &baseDelay})
+		require.Nil(t, err)
+		require.Equal(t, util.RetryPolicy{
+			MaxAttempts: 2,
+			BaseDelay:   time.Second,
+			MaxDelay:    util.DefaultRetryPolicy.MaxDelay,
+		}, policy)
+	})
+
+	t.Run("NewRetryPolicy should reject invalid config", func(t *testing.T) {
+		maxDelay := "five seconds"
+		_, err := util.NewRetryPolicy(configs.Retry{MaxDelay: &maxDelay})
+		require.NotNil(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	util "github.com/Optum/dce-cli/internal/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenListener(t *testing.T) {
+
+	// redirect mocks the DCE auth page, redirecting the browser
+	// to the localhost server with the given state and token
+	redirect := func(t *testing.T, redirectURL string, state string, token string) int {
+		callback, err := url.Parse(redirectURL)
+		require.Nil(t, err)
+		require.Equal(t, "127.0.0.1", callback.Hostname())
+
+		query := callback.Query()
+		if state != "" {
+			query.Set("state", state)
+		}
+		query.Set("token", token)
+		callback.RawQuery = query.Encode()
+
+		res, err := http.Get(callback.String())
+		require.Nil(t, err)
+		return res.StatusCode
+	}
+	acceptAll := func(token string) error { return nil }
+
+	t.Run("should return the token from the redirect", func(t *testing.T) {
+		listener := &util.TokenListenerUtil{}
+		token, err := listener.ListenForToken(context.Background(), func(redirectURL string) {
+			go redirect(t, redirectURL, "", "my-token")
+		}, acceptAll)
+		require.Nil(t, err)
+		require.Equal(t, "my-token", token)
+	})
+
+	t.Run("should ignore redirects with an unexpected state", func(t *testing.T) {
+		listener := &util.TokenListenerUtil{}
+		token, err := listener.ListenForToken(context.Background(), func(redirectURL string) {
+			require.Equal(t, http.StatusBadRequest, redirect(t, redirectURL, "forged-state", "forged-token"))
+			go redirect(t, redirectURL, "", "my-token")
+		}, acceptAll)
+		require.Nil(t, err)
+		require.Equal(t, "my-token", token)
+	})
+
+	t.Run("should fail if the token is invalid", func(t *testing.T) {
+		listener := &util.TokenListenerUtil{}
+		_, err := listener.ListenForToken(context.Background(), func(redirectURL string) {
+			require.Equal(t, http.StatusBadRequest, redirect(t, redirectURL, "", "bad-token"))
+		}, func(token string) error {
+			return errors.New("failed to decode token")
+		})
+		require.EqualError(t, err, "invalid API token: failed to decode token")
+	})
+
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		listener := &util.TokenListenerUtil{}
+		_, err := listener.ListenForToken(ctx, func(redirectURL string) {}, acceptAll)
+		require.EqualError(t, err, "timed out waiting for login to complete")
+	})
+}